@@ -2,7 +2,4 @@ module interpreters
 
 go 1.22.1
 
-require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/go-test/deep v1.1.1 // indirect
-)
+require github.com/go-test/deep v1.1.1
@@ -0,0 +1,125 @@
+package cst
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/symbols"
+)
+
+// A 1-based line and column in the source input.
+type Position struct {
+	Line uint
+	Col uint
+}
+
+// Whether `pos` comes strictly before `other`.
+func (pos Position) Before(other Position) bool {
+	return pos.Line < other.Line || (pos.Line == other.Line && pos.Col < other.Col)
+}
+
+// The source range covered by an `Element`. `End` is exclusive. Elements that cover
+// no input (e.g. Epsilon productions) have an empty span.
+type Span struct {
+	Start Position
+	End Position
+}
+
+func (span Span) IsEmpty() bool {
+	return span == Span{}
+}
+
+// Smallest span covering both spans. Empty spans are ignored.
+func (span Span) Merge(other Span) Span {
+	if (span.IsEmpty()) {
+		return other
+	}
+	if (other.IsEmpty()) {
+		return span
+	}
+
+	merged := span
+	if (other.Start.Before(merged.Start)) {
+		merged.Start = other.Start
+	}
+	if (merged.End.Before(other.End)) {
+		merged.End = other.End
+	}
+	return merged
+}
+
+/*
+An `Element` of a concrete syntax tree: either a `Node` for a reduced non-terminal
+or a `Leaf` for a shifted token.
+*/
+type Element interface {
+	Span() Span
+	// The grammar symbol of the element: the non-terminal or the token type.
+	Symbol() string
+}
+
+// ----- NODE -----
+
+type Node struct {
+	NonTerminal string
+	// ID of the production rule in `Grammar.ProductionRules` that produced this node
+	RuleId int
+	Children []Element
+	// Name of the node, `NonTerminal` unless renamed by a production annotation
+	Kind string
+	// Children named by production annotations, `nil` if the production names none
+	Fields map[string]Element
+	// Source range of the whole production, including the children dropped by
+	// production annotations. Computed from `Children` if empty.
+	SourceSpan Span
+}
+
+func NewNode(nonTerminal string, ruleId int, children []Element) *Node {
+	return &Node{nonTerminal, ruleId, children, nonTerminal, nil, Span{}}
+}
+
+// Builds the node standing for input skipped by error recovery: the partial
+// elements discarded from the parser stack and the discarded tokens.
+func NewErrorNode(children []Element) *Node {
+	return &Node{symbols.Error, -1, children, symbols.Error, nil, Span{}}
+}
+
+// Whether the node was produced by error recovery.
+func (node *Node) IsError() bool {
+	return node.RuleId < 0 && node.NonTerminal == symbols.Error
+}
+
+// Get a child named by a production annotation. Returns `nil` if the field is unset.
+func (node *Node) Field(name string) Element {
+	return node.Fields[name]
+}
+
+func (node *Node) Symbol() string { return node.NonTerminal }
+
+// The `SourceSpan` of the node if set, otherwise the span of its children.
+func (node *Node) Span() Span {
+	if (!node.SourceSpan.IsEmpty()) {
+		return node.SourceSpan
+	}
+	span := Span{}
+	for _, child := range node.Children {
+		span = span.Merge(child.Span())
+	}
+	return span
+}
+
+// ----- LEAF -----
+
+type Leaf struct {
+	Token *lexer.Token
+}
+
+func NewLeaf(token *lexer.Token) *Leaf {
+	return &Leaf{token}
+}
+
+func (leaf *Leaf) Symbol() string { return leaf.Token.Type }
+
+func (leaf *Leaf) Span() Span {
+	start := Position{leaf.Token.Line, leaf.Token.Col}
+	end := Position{leaf.Token.Line, leaf.Token.Col + uint(len(leaf.Token.Value))}
+	return Span{start, end}
+}
@@ -0,0 +1,146 @@
+package cst_test
+
+import (
+	"encoding/json"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestWalk(t *testing.T) {
+	// SUM -> num + TERM, TERM -> num, EMPTY -> EPSILON
+	tree := cst.NewNode("SUM", 0, []cst.Element{
+		cst.NewLeaf(&lexer.Token{Type: "num", Value: "12", Line: 1, Col: 1}),
+		cst.NewLeaf(&lexer.Token{Type: "+", Value: "+", Line: 1, Col: 4}),
+		cst.NewNode("TERM", 1, []cst.Element{
+			cst.NewLeaf(&lexer.Token{Type: "num", Value: "345", Line: 2, Col: 3}),
+		}),
+		cst.NewNode("EMPTY", 2, []cst.Element{}),
+	})
+
+	events := []string{}
+	cst.Walk(tree, cst.VisitorFuncs{
+		EnterFunc: func(element cst.Element) bool {
+			events = append(events, "enter "+element.Symbol())
+			// skip the children of TERM
+			return element.Symbol() != "TERM"
+		},
+		ExitFunc: func(element cst.Element) {
+			events = append(events, "exit "+element.Symbol())
+		},
+	})
+
+	expected := "enter SUM,enter num,exit num,enter +,exit +,enter TERM,exit TERM,enter EMPTY,exit EMPTY,exit SUM"
+	if (strings.Join(events, ",") != expected) {
+		t.Errorf("unexpected walk order: %s", strings.Join(events, ","))
+	}
+
+	expectedSpan := cst.Span{Start: cst.Position{Line: 1, Col: 1}, End: cst.Position{Line: 2, Col: 6}}
+	if diff := deep.Equal(tree.Span(), expectedSpan); diff != nil {
+		t.Error(diff)
+	}
+
+	nums := cst.Find(tree, func(element cst.Element) bool { return element.Symbol() == "num" })
+	if (len(nums) != 2) {
+		t.Errorf("expected 2 num leaves, found %d", len(nums))
+	}
+}
+
+func TestExport(t *testing.T) {
+	key := cst.NewLeaf(&lexer.Token{Type: "str_lit", Value: `"a"`, Line: 1, Col: 3})
+	value := cst.NewNode("VALUE", 4, []cst.Element{
+		cst.NewLeaf(&lexer.Token{Type: "num_lit", Value: "42", Line: 1, Col: 8}),
+	})
+	value.Kind = "Literal"
+	entry := cst.NewNode("ENTRY", 2, []cst.Element{key, value, cst.NewNode("EMPTY", 7, []cst.Element{})})
+	entry.Kind = "Entry"
+	entry.Fields = map[string]cst.Element{"key": key, "value": value}
+
+	expectedSExpr := `(Entry 1:3-1:10
+  key:
+  (str_lit "\"a\"" 1:3-1:6)
+  value:
+  (Literal 1:8-1:10
+    (num_lit "42" 1:8-1:10))
+  (EMPTY -))`
+	if (cst.ToSExpr(entry) != expectedSExpr) {
+		t.Errorf("unexpected S-expression:\n%s", cst.ToSExpr(entry))
+	}
+
+	bytes, err := cst.ToJSON(entry)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(bytes, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	expectedJson := map[string]any{
+		"type": "node",
+		"kind": "Entry",
+		"nonTerminal": "ENTRY",
+		"ruleId": 2.0,
+		"span": map[string]any{
+			"start": map[string]any{"line": 1.0, "col": 3.0},
+			"end": map[string]any{"line": 1.0, "col": 10.0},
+		},
+		"fields": map[string]any{"key": 0.0, "value": 1.0},
+		"children": []any{
+			map[string]any{
+				"type": "leaf",
+				"tokenType": "str_lit",
+				"value": `"a"`,
+				"span": map[string]any{
+					"start": map[string]any{"line": 1.0, "col": 3.0},
+					"end": map[string]any{"line": 1.0, "col": 6.0},
+				},
+			},
+			map[string]any{
+				"type": "node",
+				"kind": "Literal",
+				"nonTerminal": "VALUE",
+				"ruleId": 4.0,
+				"span": map[string]any{
+					"start": map[string]any{"line": 1.0, "col": 8.0},
+					"end": map[string]any{"line": 1.0, "col": 10.0},
+				},
+				"children": []any{
+					map[string]any{
+						"type": "leaf",
+						"tokenType": "num_lit",
+						"value": "42",
+						"span": map[string]any{
+							"start": map[string]any{"line": 1.0, "col": 8.0},
+							"end": map[string]any{"line": 1.0, "col": 10.0},
+						},
+					},
+				},
+			},
+			map[string]any{
+				"type": "node",
+				"kind": "EMPTY",
+				"nonTerminal": "EMPTY",
+				"ruleId": 7.0,
+			},
+		},
+	}
+	if diff := deep.Equal(decoded, expectedJson); diff != nil {
+		t.Error(diff)
+	}
+
+	dot := cst.ToDOT(entry)
+	for _, expected := range []string{
+		`n0 [shape=ellipse, label="Entry\n1:3-1:10"];`,
+		`n1 [shape=box, label="str_lit\n\"a\"\n1:3-1:6"];`,
+		`n0 -> n1 [label="key"];`,
+		`n2 -> n3;`,
+		`n0 -> n4;`,
+	} {
+		if (!strings.Contains(dot, expected)) {
+			t.Errorf("expected DOT output to contain %s, got:\n%s", expected, dot)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package cst
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ----- JSON -----
+
+type positionJson struct {
+	Line uint `json:"line"`
+	Col uint `json:"col"`
+}
+
+type spanJson struct {
+	Start positionJson `json:"start"`
+	End positionJson `json:"end"`
+}
+
+// JSON representation of an `Element`. Nodes and leaves share one shape; fields
+// that do not apply to an element are omitted.
+type elementJson struct {
+	Type string `json:"type"`
+	Kind string `json:"kind,omitempty"`
+	NonTerminal string `json:"nonTerminal,omitempty"`
+	RuleId *int `json:"ruleId,omitempty"`
+	TokenType string `json:"tokenType,omitempty"`
+	Value *string `json:"value,omitempty"`
+	Span *spanJson `json:"span,omitempty"`
+	// maps field names to indexes into `Children`
+	Fields map[string]int `json:"fields,omitempty"`
+	Children []*elementJson `json:"children,omitempty"`
+}
+
+func toSpanJson(span Span) *spanJson {
+	if (span.IsEmpty()) {
+		return nil
+	}
+	return &spanJson{
+		positionJson{span.Start.Line, span.Start.Col},
+		positionJson{span.End.Line, span.End.Col},
+	}
+}
+
+func toElementJson(element Element) *elementJson {
+	switch element := element.(type) {
+	case *Node:
+		ruleId := element.RuleId
+		children := make([]*elementJson, len(element.Children))
+		for idx, child := range element.Children {
+			children[idx] = toElementJson(child)
+		}
+
+		var fields map[string]int
+		for name, field := range element.Fields {
+			for idx, child := range element.Children {
+				if (child == field) {
+					if (fields == nil) {
+						fields = make(map[string]int)
+					}
+					fields[name] = idx
+					break
+				}
+			}
+		}
+
+		return &elementJson{
+			Type: "node",
+			Kind: element.Kind,
+			NonTerminal: element.NonTerminal,
+			RuleId: &ruleId,
+			Span: toSpanJson(element.Span()),
+			Fields: fields,
+			Children: children,
+		}
+
+	case *Leaf:
+		value := element.Token.Value
+		return &elementJson{
+			Type: "leaf",
+			TokenType: element.Token.Type,
+			Value: &value,
+			Span: toSpanJson(element.Span()),
+		}
+	}
+
+	panic(fmt.Sprintf("Unsupported element type: %T", element))
+}
+
+// Renders a tree as indented JSON for consumption by tooling in other languages.
+func ToJSON(element Element) ([]byte, error) {
+	return json.MarshalIndent(toElementJson(element), "", "  ")
+}
+
+// ----- S-EXPRESSIONS -----
+
+func formatSpan(span Span) string {
+	if (span.IsEmpty()) {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d-%d:%d", span.Start.Line, span.Start.Col, span.End.Line, span.End.Col)
+}
+
+/*
+Renders a tree as an indented S-expression, one element per line, suitable for
+golden tests:
+
+	(Entry 1:3-1:10
+	  (str_lit "\"a\"" 1:3-1:6)
+	  (Literal 1:8-1:10
+	    (num_lit "42" 1:8-1:10)))
+*/
+func ToSExpr(element Element) string {
+	var builder strings.Builder
+	writeSExpr(&builder, element, 0)
+	return builder.String()
+}
+
+func writeSExpr(builder *strings.Builder, element Element, depth int) {
+	builder.WriteString(strings.Repeat("  ", depth))
+
+	switch element := element.(type) {
+	case *Node:
+		builder.WriteString("(" + element.Kind + " " + formatSpan(element.Span()))
+		for idx, child := range element.Children {
+			builder.WriteString("\n")
+			if name := fieldName(element, idx); name != "" {
+				builder.WriteString(strings.Repeat("  ", depth + 1) + name + ":\n")
+			}
+			writeSExpr(builder, child, depth + 1)
+		}
+		builder.WriteString(")")
+
+	case *Leaf:
+		builder.WriteString(fmt.Sprintf(
+			"(%s %s %s)",
+			element.Token.Type,
+			strconv.Quote(element.Token.Value),
+			formatSpan(element.Span()),
+		))
+	}
+}
+
+// Name of the field that refers to the child at `idx`, or "" if there is none.
+func fieldName(node *Node, idx int) string {
+	names := []string{}
+	for name, field := range node.Fields {
+		if (field == node.Children[idx]) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// ----- GRAPHVIZ -----
+
+// Renders a tree as a Graphviz DOT digraph.
+func ToDOT(element Element) string {
+	var builder strings.Builder
+	builder.WriteString("digraph CST {\n")
+	builder.WriteString("  node [fontname=\"monospace\"];\n")
+
+	nextId := 0
+	var writeElement func(element Element) string
+	writeElement = func(element Element) string {
+		id := fmt.Sprintf("n%d", nextId)
+		nextId++
+
+		switch element := element.(type) {
+		case *Node:
+			label := quoteDOT(element.Kind, formatSpan(element.Span()))
+			builder.WriteString(fmt.Sprintf("  %s [shape=ellipse, label=%s];\n", id, label))
+			for idx, child := range element.Children {
+				childId := writeElement(child)
+				if name := fieldName(element, idx); name != "" {
+					builder.WriteString(fmt.Sprintf("  %s -> %s [label=%s];\n", id, childId, quoteDOT(name)))
+				} else {
+					builder.WriteString(fmt.Sprintf("  %s -> %s;\n", id, childId))
+				}
+			}
+
+		case *Leaf:
+			label := quoteDOT(element.Token.Type, element.Token.Value, formatSpan(element.Span()))
+			builder.WriteString(fmt.Sprintf("  %s [shape=box, label=%s];\n", id, label))
+		}
+
+		return id
+	}
+
+	writeElement(element)
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// Quotes a DOT label made of one or more lines.
+func quoteDOT(lines ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	escaped := make([]string, len(lines))
+	for idx, line := range lines {
+		escaped[idx] = escaper.Replace(line)
+	}
+	return `"` + strings.Join(escaped, `\n`) + `"`
+}
@@ -0,0 +1,70 @@
+package cst
+
+/*
+A `Visitor` is notified when `Walk` enters and exits every `Element` of a tree.
+Returning `false` from `Enter` skips the children of the element; `Exit` is still
+called for it.
+*/
+type Visitor interface {
+	Enter(element Element) bool
+	Exit(element Element)
+}
+
+// Adapts plain functions to a `Visitor`. Either function may be `nil`.
+type VisitorFuncs struct {
+	EnterFunc func(element Element) bool
+	ExitFunc func(element Element)
+}
+
+func (v VisitorFuncs) Enter(element Element) bool {
+	if (v.EnterFunc == nil) {
+		return true
+	}
+	return v.EnterFunc(element)
+}
+
+func (v VisitorFuncs) Exit(element Element) {
+	if (v.ExitFunc != nil) {
+		v.ExitFunc(element)
+	}
+}
+
+// Traverses a tree depth first, left to right.
+func Walk(element Element, visitor Visitor) {
+	if (visitor.Enter(element)) {
+		if node, isNode := element.(*Node); isNode {
+			for _, child := range node.Children {
+				Walk(child, visitor)
+			}
+		}
+	}
+	visitor.Exit(element)
+}
+
+// Collects every element of a tree, in `Walk` order, that satisfies `predicate`.
+func Find(element Element, predicate func(Element) bool) []Element {
+	found := []Element{}
+	Walk(element, VisitorFuncs{
+		EnterFunc: func(element Element) bool {
+			if (predicate(element)) {
+				found = append(found, element)
+			}
+			return true
+		},
+	})
+	return found
+}
+
+// Collects the leaves of a tree from left to right.
+func Leaves(element Element) []*Leaf {
+	leaves := []*Leaf{}
+	Walk(element, VisitorFuncs{
+		EnterFunc: func(element Element) bool {
+			if leaf, isLeaf := element.(*Leaf); isLeaf {
+				leaves = append(leaves, leaf)
+			}
+			return true
+		},
+	})
+	return leaves
+}
@@ -0,0 +1,127 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Expands `{NAME}` references to the `fragments` of a `LexerConfigJson`. Fragments may
+reference other fragments; each expansion is wrapped in a non-capturing group so that
+quantifiers apply to the whole fragment, e.g. `{DIGIT}+`.
+
+Only identifiers are treated as references, so regex repetitions like `{2,3}`, escaped
+braces, Unicode classes like `\p{L}` and braces inside character classes are left as
+they are.
+*/
+type fragmentExpander struct {
+	fragments map[string]string
+	expanded map[string]string
+	// fragments currently being expanded, used to detect cycles
+	expanding map[string]bool
+}
+
+func newFragmentExpander(fragments map[string]string) *fragmentExpander {
+	return &fragmentExpander{
+		fragments,
+		make(map[string]string),
+		make(map[string]bool),
+	}
+}
+
+// Expands all fragment references in `pattern`.
+func (fe *fragmentExpander) Expand(pattern string) (string, error) {
+	var result strings.Builder
+	inCharClass := false
+	// index of the first character of the current class, where `]` is a literal
+	classStart := 0
+
+	for idx := 0; idx < len(pattern); idx++ {
+		char := pattern[idx]
+		switch {
+		case char == '\\' && idx+1 < len(pattern):
+			length := readEscape(pattern[idx:])
+			result.WriteString(pattern[idx : idx+length])
+			idx += length - 1
+			continue
+		case char == '[' && !inCharClass:
+			inCharClass = true
+			classStart = idx + 1
+			if classStart < len(pattern) && pattern[classStart] == '^' {
+				classStart++
+			}
+		case char == ']' && inCharClass && idx != classStart:
+			inCharClass = false
+		case char == '{' && !inCharClass:
+			name, length := readFragmentReference(pattern[idx:])
+			if length > 0 {
+				fragment, err := fe.expandFragment(name)
+				if err != nil {
+					return "", err
+				}
+				result.WriteString("(?:" + fragment + ")")
+				idx += length - 1
+				continue
+			}
+		}
+		result.WriteByte(char)
+	}
+
+	return result.String(), nil
+}
+
+func (fe *fragmentExpander) expandFragment(name string) (string, error) {
+	if expanded, exists := fe.expanded[name]; exists {
+		return expanded, nil
+	}
+
+	fragment, exists := fe.fragments[name]
+	if !exists {
+		return "", fmt.Errorf("undefined fragment: {%s}", name)
+	}
+	if fe.expanding[name] {
+		return "", fmt.Errorf("fragment {%s} references itself", name)
+	}
+
+	fe.expanding[name] = true
+	expanded, err := fe.Expand(fragment)
+	delete(fe.expanding, name)
+	if err != nil {
+		return "", fmt.Errorf("fragment {%s}: %w", name, err)
+	}
+
+	fe.expanded[name] = expanded
+	return expanded, nil
+}
+
+// Reads a `{NAME}` reference at the start of `input`. Returns the name and the
+// length of the reference, or a length of 0 if `input` does not start with one.
+func readFragmentReference(input string) (string, int) {
+	end := strings.IndexByte(input, '}')
+	if end < 2 {
+		return "", 0
+	}
+
+	name := input[1:end]
+	for idx, char := range name {
+		isLetter := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+		isDigit := char >= '0' && char <= '9'
+		if !isLetter && !(isDigit && idx > 0) {
+			return "", 0
+		}
+	}
+
+	return name, end + 1
+}
+
+// Returns the length of the escape sequence at the start of `input`. Unicode classes
+// like `\p{Greek}` and `\P{L}` span up to their closing brace, other escapes are two
+// bytes long.
+func readEscape(input string) int {
+	if (input[1] == 'p' || input[1] == 'P') && len(input) > 2 && input[2] == '{' {
+		if end := strings.IndexByte(input, '}'); end >= 0 {
+			return end + 1
+		}
+	}
+	return 2
+}
@@ -9,7 +9,9 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"runtime"
 	"sort"
+	"sync"
 )
 
 // ----- DEFAULT PATTERNS -----
@@ -20,38 +22,132 @@ type LexerConfigJson struct {
 	KeywordTokens TokenConfigJsonArr `json:"keywordTokens"`
 	SymbolTokens TokenConfigJsonArr `json:"symbolTokens"`
 	GenericTokens TokenConfigJsonArr `json:"genericTokens"`
+	// When set, names the generic token used for identifiers and treats keywords as
+	// reserved identifiers: identifier lexemes are reclassified as keywords instead of
+	// keywords matching as prefixes of longer identifiers.
+	IdentifierToken string `json:"identifierToken,omitempty"`
+	// Named sub-patterns that token patterns can reference as `{NAME}`.
+	Fragments map[string]string `json:"fragments,omitempty"`
 }
 
+/*
+The compiled, immutable definition of a lexer. A single `Lexer` carries no scanning
+state and can be shared freely across goroutines - all per-call state lives in a
+`scanner` created by each call to `Tokenize`.
+*/
 type Lexer struct {
 	keywordTokens []*TokenConfig
 	symbolTokens []*TokenConfig
 	genericTokens []*TokenConfig
+
+	// symbols, keywords, and then generics - the order in which groups are matched
+	tokenGroups [][]*TokenConfig
+	// set when keywords are reserved identifiers
+	identifierToken *TokenConfig
+}
+
+// Per-call scanning state for a single input.
+type scanner struct {
+	lexer *Lexer
+	input string
+	processed int
 	line uint
 	col uint
 }
 
-// callback function to retrieve a `TokenConfig` from `LexerConfigJson` fields.
-func getTokenConfig(tokenConfigJson TokenConfigJson) *TokenConfig {
-	return tokenConfigJson.CreateTokenConfig()
+// Raised when the input contains a symbol that no token pattern matches.
+type LexerError struct {
+	Line uint
+	Col uint
+}
+
+func (err *LexerError) Error() string {
+	return fmt.Sprintf("Unrecognized symbol at %d:%d", err.Line, err.Col)
 }
 
-func CreateLexer(config LexerConfigJson) *Lexer {
-	// sort keyword and symbol patterns by decreasing length to ensure maximal 
+// Compiles the patterns of every token in a group.
+func compileTokenGroup(tokenConfigJsons TokenConfigJsonArr) ([]*TokenConfig, error) {
+	tokenConfigs := make([]*TokenConfig, 0, len(tokenConfigJsons))
+	for _, tokenConfigJson := range tokenConfigJsons {
+		tokenConfig, err := tokenConfigJson.CreateTokenConfig()
+		if err != nil {
+			return nil, err
+		}
+		if tokenConfig != nil {
+			tokenConfigs = append(tokenConfigs, tokenConfig)
+		}
+	}
+	return tokenConfigs, nil
+}
+
+// Expands fragment references in the patterns of a token group.
+func expandTokenGroup(tokenConfigJsons TokenConfigJsonArr, fragments *fragmentExpander) (TokenConfigJsonArr, error) {
+	expanded := make(TokenConfigJsonArr, len(tokenConfigJsons))
+	for idx, tokenConfigJson := range tokenConfigJsons {
+		pattern, err := fragments.Expand(tokenConfigJson.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("token %s: %w", tokenConfigJson.Type, err)
+		}
+		tokenConfigJson.Pattern = pattern
+		expanded[idx] = tokenConfigJson
+	}
+	return expanded, nil
+}
+
+func CreateLexer(config LexerConfigJson) (*Lexer, error) {
+	fragments := newFragmentExpander(config.Fragments)
+
+	// expand fragments on copies of each group so that the caller's config is left
+	// untouched
+	keywordTokens, err := expandTokenGroup(config.KeywordTokens, fragments)
+	if err != nil {
+		return nil, err
+	}
+	symbolTokens, err := expandTokenGroup(config.SymbolTokens, fragments)
+	if err != nil {
+		return nil, err
+	}
+	genericTokens, err := expandTokenGroup(config.GenericTokens, fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	// sort keyword and symbol patterns by decreasing length to ensure maximal
 	// length tokens are matched first
-	sort.Sort(sort.Reverse(config.KeywordTokens))
-	sort.Sort(sort.Reverse(config.SymbolTokens))
+	sort.Stable(sort.Reverse(keywordTokens))
+	sort.Stable(sort.Reverse(symbolTokens))
 
-	keywords := arrays.Map(config.KeywordTokens, getTokenConfig)
-	symbols := arrays.Map(config.SymbolTokens, getTokenConfig)
-	generics := arrays.Map(config.GenericTokens, getTokenConfig)
+	keywords, err := compileTokenGroup(keywordTokens)
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := compileTokenGroup(symbolTokens)
+	if err != nil {
+		return nil, err
+	}
+	generics, err := compileTokenGroup(genericTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var identifierToken *TokenConfig
+	if (config.IdentifierToken != "") {
+		match := arrays.FindFirst(generics, func (tokenConfig *TokenConfig) bool {
+			return tokenConfig.Type == config.IdentifierToken
+		})
+		if (match == nil) {
+			return nil, fmt.Errorf("Identifier token: %s is not a generic token", config.IdentifierToken)
+		}
+		identifierToken = *match
+	}
 
 	return &Lexer{
 		keywords,
 		symbols,
 		generics,
-		0,
-		0,
-	}
+		[][]*TokenConfig{symbols, keywords, generics},
+		identifierToken,
+	}, nil
 }
 
 func CreateLexerFromJsonConfig(path string) (*Lexer, error) {
@@ -72,16 +168,13 @@ func CreateLexerFromJsonConfig(path string) (*Lexer, error) {
 		return nil, errors.New(`Error unmarshalling config file: ` + err.Error())
     }
 
-	return CreateLexer(data), nil
+	return CreateLexer(data)
 }
 
 func (lex *Lexer) matchTokenGroup(tokenConfigs []*TokenConfig, inputStream string) *Token {
 	for _, tokenConfig := range tokenConfigs {
 		token := tokenConfig.Match(inputStream)
 		if token != nil {
-			token.Col = lex.col + 1
-			token.Line = lex.line + 1
-			lex.col += uint(len(token.Value))
 			return token
 		}
 	}
@@ -89,60 +182,148 @@ func (lex *Lexer) matchTokenGroup(tokenConfigs []*TokenConfig, inputStream strin
 	return nil
 }
 
-func (lex *Lexer) Tokenize(input string) *[]*Token {
-	result := make([]*Token, 0)
-	processed := 0
-	lex.line = 0
-	lex.col = 0
-	tokenGroups := [][]*TokenConfig{
-		lex.symbolTokens,
-		lex.keywordTokens,
-		lex.genericTokens,
+// Matches the next token at the start of `inputStream`. Returns `nil` if no token
+// pattern matches.
+func (lex *Lexer) matchToken(inputStream string) *Token {
+	if (lex.identifierToken == nil) {
+		// match symbols, keywords, and then generics - in that order
+		for _, tokenGroup := range lex.tokenGroups {
+			if token := lex.matchTokenGroup(tokenGroup, inputStream); token != nil {
+				return token
+			}
+		}
+		return nil
 	}
 
-	for processed < len(input) {
-		currInput := input[processed:]
+	// keywords are reserved identifiers: match whole identifiers and reclassify
+	// the ones that spell a keyword
+	if token := lex.matchTokenGroup(lex.symbolTokens, inputStream); token != nil {
+		return token
+	}
+	if token := lex.identifierToken.Match(inputStream); token != nil {
+		for _, keyword := range lex.keywordTokens {
+			if (keyword.MatchesExactly(token.Value)) {
+				token.Type = keyword.Type
+				break
+			}
+		}
+		return token
+	}
+	if token := lex.matchTokenGroup(lex.keywordTokens, inputStream); token != nil {
+		return token
+	}
+	return lex.matchTokenGroup(lex.genericTokens, inputStream)
+}
+
+func (sc *scanner) scan() (*[]*Token, error) {
+	result := make([]*Token, 0)
+	input := sc.input
+
+	for sc.processed < len(input) {
+		currInput := input[sc.processed:]
 
 		// check for newlines
 		if (newlinePattern.MatchString(currInput)) {
-			processed++
-			lex.line++
-			lex.col = 0
+			sc.processed++
+			sc.line++
+			sc.col = 0
 			continue
 		}
 		// check for whitespaces
 		if (whitespacePattern.MatchString(currInput)) {
-			processed++
-			lex.col++
+			sc.processed++
+			sc.col++
 			continue
 		}
 
-		// match symbols, keywords, and then generics - in that order
-		var token *Token
-		for _, tokenGroup := range tokenGroups {
-			token = lex.matchTokenGroup(tokenGroup, currInput)
-			if (token != nil) {
-				break
-			}
-		}
-
+		token := sc.lexer.matchToken(currInput)
 		if (token == nil) {
-			message := fmt.Sprintf("Unrecognized symbol at %d:%d", lex.line, lex.col)
-			panic(message)
-		} 
+			return nil, &LexerError{sc.line + 1, sc.col + 1}
+		}
+		token.Line = sc.line + 1
+		token.Col = sc.col + 1
+		sc.col += uint(len(token.Value))
 
 		result = append(result, token)
-		processed += len(token.Value)
+		sc.processed += len(token.Value)
 	}
 
+	// terminate the stream with an end of input token positioned after the input
 	result = append(result, &Token{
-		symbols.Epsilon,
-		symbols.Epsilon,
-		0,
-		0,
+		symbols.EOF,
+		"",
+		sc.line + 1,
+		sc.col + 1,
 	})
 
-	return &result
+	return &result, nil
+}
+
+// Get the compiled token groups in the order they are matched: symbols, keywords
+// and then generics.
+func (lex *Lexer) TokenGroups() (symbols []*TokenConfig, keywords []*TokenConfig, generics []*TokenConfig) {
+	return lex.symbolTokens, lex.keywordTokens, lex.genericTokens
+}
+
+// Get the identifier token when keywords are reserved identifiers, `nil` otherwise.
+func (lex *Lexer) IdentifierToken() *TokenConfig {
+	return lex.identifierToken
 }
 
+// Tokenizes a single input. Safe to call concurrently on a shared `Lexer`.
+func (lex *Lexer) Tokenize(input string) (*[]*Token, error) {
+	sc := scanner{lexer: lex, input: input}
+	return sc.scan()
+}
 
+// Same as `Tokenize` but panics if the input contains an unrecognized symbol.
+func (lex *Lexer) MustTokenize(input string) *[]*Token {
+	tokens, err := lex.Tokenize(input)
+	if err != nil {
+		panic(err.Error())
+	}
+	return tokens
+}
+
+// Tokenizes many inputs in parallel using a worker pool bounded by `GOMAXPROCS`.
+// See `TokenizeAllWithWorkers`.
+func (lex *Lexer) TokenizeAll(inputs []string) ([]*[]*Token, error) {
+	return lex.TokenizeAllWithWorkers(inputs, runtime.GOMAXPROCS(0))
+}
+
+// Tokenizes many inputs in parallel using at most `workers` goroutines. Results
+// are returned in the same order as `inputs`. If any input fails to tokenize, the
+// error of the first failing input (by index) is returned.
+func (lex *Lexer) TokenizeAllWithWorkers(inputs []string, workers int) ([]*[]*Token, error) {
+	results := make([]*[]*Token, len(inputs))
+	errs := make([]error, len(inputs))
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(inputs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx], errs[idx] = lex.Tokenize(inputs[idx])
+			}
+		}()
+	}
+
+	for idx := range inputs {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	for idx, err := range errs {
+		if err != nil {
+			return results, fmt.Errorf("input %d: %w", idx, err)
+		}
+	}
+
+	return results, nil
+}
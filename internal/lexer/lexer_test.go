@@ -1,6 +1,7 @@
 package lexer_test
 
 import (
+	"fmt"
 	"interpreters/internal/lexer"
 	"testing"
 
@@ -27,6 +28,7 @@ func TestLexer(t *testing.T) {
 				{":", ":", 1, 11},
 				{"true", "true", 1, 13},
 				{"}", "}", 1, 18},
+				{"$", "", 1, 19},
 			},
 		},
 		{
@@ -40,6 +42,7 @@ func TestLexer(t *testing.T) {
 				{":", ":", 2, 13},
 				{"true", "true", 2, 15},
 				{"}", "}", 3, 5},
+				{"$", "", 3, 6},
 			},
 		},
 		{
@@ -47,6 +50,7 @@ func TestLexer(t *testing.T) {
 			`true`, 
 			[]*lexer.Token{
 				{"true", "true", 1, 1},
+				{"$", "", 1, 5},
 			},
 		},
 		{
@@ -62,16 +66,273 @@ func TestLexer(t *testing.T) {
 				{",", ",", 1, 24},
 				{"num_lit", "-2.45", 1, 26},
 				{"]", "]", 1, 32},
+				{"$", "", 1, 33},
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			output := Lexer.Tokenize(tc.input)
+			output, err := Lexer.Tokenize(tc.input)
+			if (err != nil) {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(*output, tc.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLexerReportsUnrecognizedSymbols(t *testing.T) {
+	Lexer, err := lexer.CreateLexerFromJsonConfig("./token-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize lexer: ", err.Error())
+	}
+
+	_, err = Lexer.Tokenize("{\n  \"a\": @ }")
+	if diff := deep.Equal(err, error(&lexer.LexerError{Line: 2, Col: 8})); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestTokenizeAll(t *testing.T) {
+	Lexer, err := lexer.CreateLexerFromJsonConfig("./token-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize lexer: ", err.Error())
+	}
+
+	inputs := make([]string, 64)
+	for i := range inputs {
+		inputs[i] = fmt.Sprintf(`{ "prop_%d": [ %d, true ] }`, i, i)
+	}
+
+	outputs, err := Lexer.TokenizeAllWithWorkers(inputs, 4)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	// every parallel result must match a sequential run on the same shared lexer
+	for i, input := range inputs {
+		expected, _ := Lexer.Tokenize(input)
+		if diff := deep.Equal(*outputs[i], *expected); diff != nil {
+			t.Errorf("input %d: %v", i, diff)
+		}
+	}
+
+	_, err = Lexer.TokenizeAll([]string{"true", "@", "false", "#"})
+	if (err == nil || err.Error() != "input 1: Unrecognized symbol at 1:1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestKeywordOptions(t *testing.T) {
+	symbolTokens := lexer.TokenConfigJsonArr{
+		{Type: "(", Pattern: `(\()`},
+		{Type: ")", Pattern: `(\))`},
+	}
+	keywordTokens := lexer.TokenConfigJsonArr{
+		{Type: "null", Pattern: "(null)", WholeWord: true},
+		{Type: "select", Pattern: "(select)", CaseInsensitive: true},
+	}
+	genericTokens := lexer.TokenConfigJsonArr{
+		{Type: "ident", Pattern: `([A-Za-z_]\w*)`},
+	}
+
+	var testCases = []struct{
+		name string
+		identifierToken string
+		input string
+		output []*lexer.Token
+	}{
+		{
+			"Whole word keywords do not match identifier prefixes.",
+			"",
+			`null nullable`,
+			[]*lexer.Token{
+				{"null", "null", 1, 1},
+				{"ident", "nullable", 1, 6},
+				{"$", "", 1, 14},
+			},
+		},
+		{
+			"Case insensitive keywords match any letter case.",
+			"",
+			`SELECT Select(x)`,
+			[]*lexer.Token{
+				{"select", "SELECT", 1, 1},
+				{"select", "Select", 1, 8},
+				{"(", "(", 1, 14},
+				{"ident", "x", 1, 15},
+				{")", ")", 1, 16},
+				{"$", "", 1, 17},
+			},
+		},
+		{
+			"Keywords are reserved identifiers.",
+			"ident",
+			`selection SeLeCt null_ptr null`,
+			[]*lexer.Token{
+				{"ident", "selection", 1, 1},
+				{"select", "SeLeCt", 1, 11},
+				{"ident", "null_ptr", 1, 18},
+				{"null", "null", 1, 27},
+				{"$", "", 1, 31},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			Lexer, err := lexer.CreateLexer(lexer.LexerConfigJson{
+				KeywordTokens: keywordTokens,
+				SymbolTokens: symbolTokens,
+				GenericTokens: genericTokens,
+				IdentifierToken: tc.identifierToken,
+			})
+			if (err != nil) {
+				t.Fatal(err)
+			}
+			output, err := Lexer.Tokenize(tc.input)
+			if (err != nil) {
+				t.Fatal(err)
+			}
 			if diff := deep.Equal(*output, tc.output); diff != nil {
 				t.Error(diff)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestFragments(t *testing.T) {
+	config := lexer.LexerConfigJson{
+		Fragments: map[string]string{
+			"DIGIT": `[0-9]`,
+			"EXPONENT": `[eE][+-]?{DIGIT}+`,
+			"HEX": `[0-9a-fA-F]`,
+		},
+		GenericTokens: lexer.TokenConfigJsonArr{
+			{Type: "num_lit", Pattern: `-?{DIGIT}+(\.{DIGIT}+)?{EXPONENT}?`},
+			{Type: "str_lit", Pattern: `"(\\u{HEX}{4}|[^"\\{}])*"`},
+		},
+	}
+
+	Lexer, err := lexer.CreateLexer(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	output, err := Lexer.Tokenize(`-1.5e+10 "\u00e9x" 42`)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := []*lexer.Token{
+		{"num_lit", "-1.5e+10", 1, 1},
+		{"str_lit", `"\u00e9x"`, 1, 10},
+		{"num_lit", "42", 1, 20},
+		{"$", "", 1, 22},
+	}
+	if diff := deep.Equal(*output, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	var errorCases = []struct{
+		name string
+		fragments map[string]string
+		pattern string
+		message string
+	}{
+		{
+			"Undefined fragments are rejected.",
+			map[string]string{},
+			`{DIGIT}+`,
+			"token num_lit: undefined fragment: {DIGIT}",
+		},
+		{
+			"Cyclic fragments are rejected.",
+			map[string]string{"A": `a{B}`, "B": `b{A}`},
+			`{A}`,
+			"token num_lit: fragment {A}: fragment {B}: fragment {A} references itself",
+		},
+		{
+			"Invalid expanded patterns are rejected.",
+			map[string]string{"OPEN": `(`},
+			`{OPEN}`,
+			"token num_lit has an invalid pattern: error parsing regexp: missing closing ): `^(?:(?:())`",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := lexer.CreateLexer(lexer.LexerConfigJson{
+				Fragments: tc.fragments,
+				GenericTokens: lexer.TokenConfigJsonArr{{Type: "num_lit", Pattern: tc.pattern}},
+			})
+			if (err == nil || err.Error() != tc.message) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFragmentsKeepUnicodeClasses(t *testing.T) {
+	var testCases = []struct{
+		name string
+		fragments map[string]string
+	}{
+		{"Unicode classes work without fragments.", nil},
+		{"Unicode classes are not read as fragment references.", map[string]string{"DIGIT": `[0-9]`}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			Lexer, err := lexer.CreateLexer(lexer.LexerConfigJson{
+				Fragments: tc.fragments,
+				GenericTokens: lexer.TokenConfigJsonArr{
+					{Type: "word", Pattern: `\p{L}+`},
+					{Type: "not_greek", Pattern: `\P{Greek}`},
+				},
+			})
+			if (err != nil) {
+				t.Fatal(err)
+			}
+			output, err := Lexer.Tokenize(`héllo λ 7`)
+			if (err != nil) {
+				t.Fatal(err)
+			}
+			expected := []*lexer.Token{
+				{"word", "héllo", 1, 1},
+				{"word", "λ", 1, 8},
+				{"not_greek", "7", 1, 11},
+				{"$", "", 1, 12},
+			}
+			if diff := deep.Equal(*output, expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestFragmentsKeepLiteralClosingBrackets(t *testing.T) {
+	Lexer, err := lexer.CreateLexer(lexer.LexerConfigJson{
+		Fragments: map[string]string{"DIGIT": `[0-9]`},
+		GenericTokens: lexer.TokenConfigJsonArr{
+			{Type: "bracket", Pattern: `[]{X}]+`},
+			{Type: "other", Pattern: `[^]{X} ]{DIGIT}`},
+		},
+	})
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	output, err := Lexer.Tokenize(`]{X} +1`)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := []*lexer.Token{
+		{"bracket", "]{X}", 1, 1},
+		{"other", "+1", 1, 6},
+		{"$", "", 1, 8},
+	}
+	if diff := deep.Equal(*output, expected); diff != nil {
+		t.Error(diff)
+	}
+}
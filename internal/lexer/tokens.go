@@ -1,7 +1,10 @@
 package lexer
 
 import (
+	"fmt"
 	"regexp"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Token struct {
@@ -14,6 +17,10 @@ type Token struct {
 type TokenConfigJson struct {
 	Type string `json:"type"`
 	Pattern string `json:"pattern"`
+	// match the pattern regardless of letter case
+	CaseInsensitive bool `json:"caseInsensitive,omitempty"`
+	// only match if the token is not immediately followed by another word character
+	WholeWord bool `json:"wholeWord,omitempty"`
 }
 
 // implementations for sort.Interface
@@ -22,28 +29,43 @@ func (arr TokenConfigJsonArr) Len() int 				{ return len(arr) }
 func (arr TokenConfigJsonArr) Swap(i int, j int) 		{ arr[i], arr[j] = arr[j], arr[i] }
 func (arr TokenConfigJsonArr) Less(i int, j int) bool 	{ return len(arr[i].Pattern) < len(arr[j].Pattern) }
 
-func (json *TokenConfigJson) CreateTokenConfig() *TokenConfig {
+// Compiles the pattern of a token. Returns `nil` for tokens without a pattern.
+func (json *TokenConfigJson) CreateTokenConfig() (*TokenConfig, error) {
 	if (len(json.Pattern) <= 0) {
-		return nil
-	} else if (json.Pattern[0] == '^') {
-		regex := regexp.MustCompile(json.Pattern)
-		return &TokenConfig{
-			json.Type,
-			regex,
-		}
-	} else {
-		matchStartPattern := "^" + json.Pattern
-		regex := regexp.MustCompile(matchStartPattern)
-		return &TokenConfig{
-			json.Type,
-			regex,
-		}
+		return nil, nil
+	}
+
+	pattern := json.Pattern
+	if (pattern[0] == '^') {
+		pattern = pattern[1:]
+	}
+	flags := ""
+	if (json.CaseInsensitive) {
+		flags = "(?i)"
+	}
+
+	// the pattern is wrapped in a group so that alternations are anchored as a whole
+	regex, err := regexp.Compile("^" + flags + "(?:" + pattern + ")")
+	if err != nil {
+		return nil, fmt.Errorf("token %s has an invalid pattern: %w", json.Type, err)
 	}
+	exactRegex := regexp.MustCompile("^" + flags + "(?:" + pattern + ")$")
+
+	return &TokenConfig{
+		json.Type,
+		regex,
+		json.WholeWord,
+		exactRegex,
+	}, nil
 }
 
 type TokenConfig struct {
 	Type string
 	Pattern *regexp.Regexp
+	WholeWord bool
+
+	// `Pattern` anchored at both ends, used to test complete lexemes
+	exactPattern *regexp.Regexp
 }
 
 /*
@@ -54,12 +76,35 @@ func (tokenConfig *TokenConfig) Match(input string) *Token {
 	match := tokenConfig.Pattern.FindStringSubmatch(input)
 	if (match == nil) {
 		return nil
-	} else {
-		return &Token{
-			tokenConfig.Type,
-			match[0],
-			0,
-			0,
-		}
 	}
-}
\ No newline at end of file
+	if (tokenConfig.WholeWord && splitsWord(match[0], input[len(match[0]):])) {
+		return nil
+	}
+	return &Token{
+		tokenConfig.Type,
+		match[0],
+		0,
+		0,
+	}
+}
+
+// Get the source of the pattern used by `MatchesExactly`.
+func (tokenConfig *TokenConfig) ExactPattern() string {
+	return tokenConfig.exactPattern.String()
+}
+
+// Checks whether a complete lexeme (e.g. an identifier) matches this `TokenConfig`.
+func (tokenConfig *TokenConfig) MatchesExactly(lexeme string) bool {
+	return tokenConfig.exactPattern.MatchString(lexeme)
+}
+
+// Whether a match ending in a word character is immediately followed by another.
+func splitsWord(match string, rest string) bool {
+	last, _ := utf8.DecodeLastRuneInString(match)
+	next, size := utf8.DecodeRuneInString(rest)
+	return size > 0 && isWordRune(last) && isWordRune(next)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
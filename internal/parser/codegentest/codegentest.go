@@ -0,0 +1,105 @@
+/*
+Test helpers shared by the parser code generators: checks on the generated source
+and a harness that compiles a generated parser and runs it on inputs.
+*/
+package codegentest
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Driver of the generated parser: parses every line of stdin and prints the symbol
+// and child count of the root, or the error.
+const driverSource = `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		node, err := Parse(scanner.Text())
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(node.Symbol, len(node.Children))
+	}
+}
+`
+
+// Runs `generate` twice and checks that the source is gofmt-formatted and the same
+// both times. Returns the generated source.
+func CheckSource(t *testing.T, generate func () ([]byte, error)) []byte {
+	t.Helper()
+	source, err := generate()
+	if (err != nil) {
+		t.Fatal("Failed to generate parser: ", err.Error())
+	}
+
+	formatted, err := format.Source(source)
+	if (err != nil) {
+		t.Fatalf("generated source does not parse: %v", err)
+	}
+	if (!bytes.Equal(formatted, source)) {
+		t.Errorf("generated source is not gofmt-formatted")
+	}
+
+	again, err := generate()
+	if (err != nil || !bytes.Equal(again, source)) {
+		t.Errorf("generated source is not deterministic")
+	}
+	return source
+}
+
+/*
+Compiles the generated source of a `main` package along with a driver and parses each
+input with it. Each input must be a single line; the lines printed by the driver are
+compared with `expected`.
+
+Skipped in short mode or without a go tool.
+*/
+func RunParser(t *testing.T, source []byte, inputs []string, expected []string) {
+	t.Helper()
+	if (testing.Short()) {
+		t.Skip("compiles a Go program")
+	}
+	goTool, err := exec.LookPath("go")
+	if (err != nil) {
+		t.Skip("go tool not available")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module generated\n\ngo 1.22\n",
+		"parser.go": string(source),
+		"main.go": driverSource,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	cmd.Stdin = strings.NewReader(strings.Join(inputs, "\n") + "\n")
+	output, err := cmd.CombinedOutput()
+	if (err != nil) {
+		t.Fatalf("generated parser failed: %v\n%s", err, output)
+	}
+
+	if (string(output) != strings.Join(expected, "\n") + "\n") {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", output, strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,106 @@
+package cyk_test
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/cyk"
+	"interpreters/internal/parser/grammartransform"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/parsertest"
+	"interpreters/internal/symbols"
+	"strings"
+	"testing"
+)
+
+// Every token stream over the terminals with at most `maxLength` tokens, EOF-terminated.
+func tokenStreams(terminals []string, maxLength int) [][]*lexer.Token {
+	streams := [][]*lexer.Token{}
+	current := [][]string{{}}
+	for length := 0; length <= maxLength; length++ {
+		next := [][]string{}
+		for _, types := range current {
+			tokens := []*lexer.Token{}
+			for _, typ := range types {
+				tokens = append(tokens, &lexer.Token{Type: typ, Value: typ})
+			}
+			streams = append(streams, append(tokens, &lexer.Token{Type: symbols.EOF}))
+			for _, terminal := range terminals {
+				next = append(next, append(append([]string{}, types...), terminal))
+			}
+		}
+		current = next
+	}
+	return streams
+}
+
+func describe(tokens []*lexer.Token) string {
+	types := []string{}
+	for _, token := range tokens {
+		types = append(types, token.Type)
+	}
+	return strings.Join(types, " ")
+}
+
+// Checks that CYK on the Chomsky Normal Form accepts exactly what the LR(1) parser accepts.
+func compareWithLR1(t *testing.T, config lr1grammar.GrammarConfigJson, terminals []string, maxLength int, cnf *lr1grammar.Grammar) {
+	t.Helper()
+	lr1, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	recognizer, err := cyk.NewRecognizer(cnf)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	accepted := 0
+	for _, tokens := range tokenStreams(terminals, maxLength) {
+		_, err := lr1.ParseTokens(tokens)
+		expected := err == nil
+		if (expected) {
+			accepted++
+		}
+		if (recognizer.Recognize(tokens) != expected) {
+			t.Errorf("%q: expected accepted = %v", describe(tokens), expected)
+		}
+	}
+	if (accepted == 0) {
+		t.Error("no input accepted")
+	}
+}
+
+var arithmetic = lr1grammar.GrammarConfigJson{
+	Terminals: parsertest.SymbolTokens("+", "*", "(", ")", "num"),
+	NonTerminals: map[string][]lr1grammar.ProductionJson{
+		"E": lr1grammar.NewProductionsJson([]string{"E", "+", "T"}, []string{"T"}),
+		"T": lr1grammar.NewProductionsJson([]string{"T", "*", "F"}, []string{"F"}),
+		"F": lr1grammar.NewProductionsJson([]string{"(", "E", ")"}, []string{"num"}),
+	},
+	StartSymbol: "E",
+}
+
+// Balanced parentheses, with a nullable start symbol appearing on a RHS.
+var balanced = lr1grammar.GrammarConfigJson{
+	Terminals: parsertest.SymbolTokens("(", ")"),
+	NonTerminals: map[string][]lr1grammar.ProductionJson{
+		"S": lr1grammar.NewProductionsJson([]string{"(", "S", ")", "S"}, []string{symbols.Epsilon}),
+	},
+	StartSymbol: "S",
+}
+
+func TestRecognizerMatchesLR1OnCNF(t *testing.T) {
+	compareWithLR1(t, arithmetic, []string{"+", "*", "(", ")", "num"}, 5, grammartransform.ToCNF(lr1grammar.MustNewGrammar(arithmetic)))
+	compareWithLR1(t, balanced, []string{"(", ")"}, 8, grammartransform.ToCNF(lr1grammar.MustNewGrammar(balanced)))
+}
+
+func TestRecognizerMatchesLR1OnCNFOfGNF(t *testing.T) {
+	compareWithLR1(t, arithmetic, []string{"+", "*", "(", ")", "num"}, 5, grammartransform.ToCNF(grammartransform.ToGNF(lr1grammar.MustNewGrammar(arithmetic))))
+	compareWithLR1(t, balanced, []string{"(", ")"}, 8, grammartransform.ToCNF(grammartransform.ToGNF(lr1grammar.MustNewGrammar(balanced))))
+}
+
+func TestRecognizerRejectsGrammarsNotInCNF(t *testing.T) {
+	_, err := cyk.NewRecognizer(lr1grammar.MustNewGrammar(arithmetic))
+	if (err == nil || !strings.HasPrefix(err.Error(), "Grammar is not in Chomsky Normal Form: ")) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package cyk
+
+import (
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
+	"interpreters/utilities/bitsets"
+)
+
+/*
+A CYK recognizer: decides membership for a grammar in Chomsky Normal Form in
+O(n³ · |rules|) with a table of the non-terminals deriving each span of the input.
+Meant for comparison with the other parsers on the output of `grammartransform.ToCNF`,
+it builds no tree.
+*/
+type Recognizer struct {
+	Grammar *lr1grammar.Grammar
+	// rules `A -> a`, by terminal
+	terminalRules map[int][]int
+	// rules `A -> B C`
+	binaryRules []int
+	acceptsEmpty bool
+}
+
+func NewRecognizer(grammar *lr1grammar.Grammar) (*Recognizer, error) {
+	r := &Recognizer{Grammar: grammar, terminalRules: make(map[int][]int)}
+	start, _ := grammar.Symbols.Id(grammar.StartSymbol)
+	for ruleId := 0; ruleId < len(grammar.ProductionRules); ruleId++ {
+		lhs := grammar.RuleLHS(ruleId)
+		rhs := grammar.RuleRHS(ruleId)
+		switch {
+		case len(rhs) == 0 && lhs == start:
+			r.acceptsEmpty = true
+		case len(rhs) == 1 && grammar.Symbols.IsTerminal(rhs[0]):
+			r.terminalRules[rhs[0]] = append(r.terminalRules[rhs[0]], ruleId)
+		case len(rhs) == 2 && !grammar.Symbols.IsTerminal(rhs[0]) && !grammar.Symbols.IsTerminal(rhs[1]):
+			r.binaryRules = append(r.binaryRules, ruleId)
+		default:
+			rule := grammar.ProductionRules[uint(ruleId)]
+			return nil, fmt.Errorf(`Grammar is not in Chomsky Normal Form: %s -> %v`, rule.NonTerminal, rule.Production)
+		}
+	}
+	return r, nil
+}
+
+// Whether a token stream terminated by an EOF token is in the language of the grammar.
+func (r *Recognizer) Recognize(tokens []*lexer.Token) bool {
+	if (len(tokens) > 0 && tokens[len(tokens) - 1].Type == symbols.EOF) {
+		tokens = tokens[:len(tokens) - 1]
+	}
+	start, _ := r.Grammar.Symbols.Id(r.Grammar.StartSymbol)
+	n := len(tokens)
+	if (n == 0) {
+		return r.acceptsEmpty
+	}
+
+	// table[length - 1][begin] holds the non-terminals deriving tokens[begin:begin + length]
+	table := make([][]bitsets.Bitset, n)
+	for length := 1; length <= n; length++ {
+		table[length - 1] = make([]bitsets.Bitset, n - length + 1)
+		for begin := range table[length - 1] {
+			table[length - 1][begin] = bitsets.New(r.Grammar.Symbols.Len())
+		}
+	}
+
+	for begin, token := range tokens {
+		terminal, exists := r.Grammar.Symbols.Id(token.Type)
+		if (!exists) {
+			return false
+		}
+		for _, ruleId := range r.terminalRules[terminal] {
+			table[0][begin].Add(r.Grammar.RuleLHS(ruleId))
+		}
+	}
+
+	for length := 2; length <= n; length++ {
+		for begin := 0; begin + length <= n; begin++ {
+			cell := table[length - 1][begin]
+			for split := 1; split < length; split++ {
+				left := table[split - 1][begin]
+				right := table[length - split - 1][begin + split]
+				for _, ruleId := range r.binaryRules {
+					rhs := r.Grammar.RuleRHS(ruleId)
+					if (left.Has(rhs[0]) && right.Has(rhs[1])) {
+						cell.Add(r.Grammar.RuleLHS(ruleId))
+					}
+				}
+			}
+		}
+	}
+	return table[n - 1][0].Has(start)
+}
@@ -0,0 +1,141 @@
+package earley
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/symbols"
+	"interpreters/utilities/bitsets"
+)
+
+// A production rule with a dot, started at token `origin`.
+type item struct {
+	rule int
+	dot int
+	origin int
+}
+
+// Items of the chart at one position of the input.
+type earleySet struct {
+	items []item
+	index map[item]bool
+	// rules of the complete items, by LHS and origin
+	completed map[[2]int][]int
+}
+
+func newEarleySet() *earleySet {
+	return &earleySet{index: make(map[item]bool), completed: make(map[[2]int][]int)}
+}
+
+func (set *earleySet) add(grammar *lr1grammar.Grammar, it item) {
+	if (set.index[it]) {
+		return
+	}
+	set.index[it] = true
+	set.items = append(set.items, it)
+	if (it.dot == len(grammar.RuleRHS(it.rule))) {
+		key := [2]int{grammar.RuleLHS(it.rule), it.origin}
+		set.completed[key] = append(set.completed[key], it.rule)
+	}
+}
+
+// The Earley sets of a token stream: `sets[i]` holds the items after `i` tokens.
+type chart struct {
+	grammar *lr1grammar.Grammar
+	tokens []*lexer.Token
+	sets []*earleySet
+	start int
+}
+
+/*
+Fills the chart one token at a time: predictions and completions run to a fixpoint
+on the current set, then the token is scanned into the next one. Predicting a
+nullable non-terminal also moves the dot over it (Aycock and Horspool), so that
+completions of Epsilon derivations in the current set are never missed.
+*/
+func (p *Parser) recognize(tokens []*lexer.Token) (*chart, error) {
+	if err := lr1parser.CheckTokenStream(tokens); err != nil {
+		return nil, err
+	}
+
+	grammar := p.Grammar
+	augmentedStart, _ := grammar.Symbols.Id(symbols.AugmentedStart)
+	startRule := grammar.RulesOf(augmentedStart)[0]
+
+	// the EOF token is never scanned
+	length := len(tokens) - 1
+	c := &chart{grammar, tokens, make([]*earleySet, length + 1), grammar.RuleRHS(startRule)[0]}
+	for idx := range c.sets {
+		c.sets[idx] = newEarleySet()
+	}
+	c.sets[0].add(grammar, item{startRule, 0, 0})
+
+	for position := 0; position <= length; position++ {
+		set := c.sets[position]
+		for idx := 0; idx < len(set.items); idx++ {
+			it := set.items[idx]
+			rhs := grammar.RuleRHS(it.rule)
+
+			if (it.dot == len(rhs)) {
+				// complete: advance the items that were waiting for the LHS
+				lhs := grammar.RuleLHS(it.rule)
+				origin := c.sets[it.origin]
+				for waitingIdx := 0; waitingIdx < len(origin.items); waitingIdx++ {
+					waiting := origin.items[waitingIdx]
+					waitingRHS := grammar.RuleRHS(waiting.rule)
+					if (waiting.dot < len(waitingRHS) && waitingRHS[waiting.dot] == lhs) {
+						set.add(grammar, item{waiting.rule, waiting.dot + 1, waiting.origin})
+					}
+				}
+				continue
+			}
+
+			next := rhs[it.dot]
+			if (grammar.Symbols.IsTerminal(next)) {
+				// scan
+				if (position < length && tokens[position].Type == grammar.Symbols.Name(next)) {
+					c.sets[position + 1].add(grammar, item{it.rule, it.dot + 1, it.origin})
+				}
+				continue
+			}
+
+			// predict
+			for _, rule := range grammar.RulesOf(next) {
+				set.add(grammar, item{rule, 0, position})
+			}
+			if (grammar.IsNullable(next)) {
+				set.add(grammar, item{it.rule, it.dot + 1, it.origin})
+			}
+		}
+
+		if (position < length && len(c.sets[position + 1].items) == 0) {
+			return nil, c.syntaxError(position)
+		}
+	}
+
+	if (!c.sets[length].index[item{startRule, 1, 0}]) {
+		return nil, c.syntaxError(length)
+	}
+	return c, nil
+}
+
+// Reports the token at a position as unexpected, expecting the terminals the items
+// of its set were about to scan.
+func (c *chart) syntaxError(position int) error {
+	expected := bitsets.New(c.grammar.Symbols.Len())
+	for _, it := range c.sets[position].items {
+		rhs := c.grammar.RuleRHS(it.rule)
+		if (it.dot < len(rhs) && c.grammar.Symbols.IsTerminal(rhs[it.dot])) {
+			expected.Add(rhs[it.dot])
+		}
+	}
+	if (len(c.sets[position].completed[[2]int{c.start, 0}]) > 0) {
+		// the input could have ended here
+		eof, _ := c.grammar.Symbols.Id(symbols.EOF)
+		expected.Add(eof)
+	}
+	if errorId, exists := c.grammar.Symbols.Id(symbols.Error); exists {
+		expected.Delete(errorId)
+	}
+	return lr1parser.NewSyntaxError(c.grammar.DisplayName, c.tokens[position], c.grammar.Symbols.Names(expected))
+}
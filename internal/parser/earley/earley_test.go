@@ -0,0 +1,151 @@
+package earley_test
+
+import (
+	"errors"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/earley"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/parsertest"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParserMatchesLR1Trees(t *testing.T) {
+	lr1, err := lr1parser.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	parser, err := earley.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{
+		`{ "a": [1, 2, { "b": null }], "c": true }`,
+		`[]`,
+		`"text"`,
+	} {
+		expected, err := lr1.ParseString(input)
+		if (err != nil) {
+			t.Fatal(err)
+		}
+		tree, err := parser.ParseString(input)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if diff := deep.Equal(tree, expected); diff != nil {
+			t.Errorf("%q: %v", input, diff)
+		}
+	}
+}
+
+func TestParserHandlesAnyContextFreeGrammar(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "+", Pattern: `(\+)`},
+				{Type: "*", Pattern: `(\*)`},
+				{Type: "(", Pattern: `(\()`},
+				{Type: ")", Pattern: `(\))`},
+			},
+			GenericTokens: lexer.TokenConfigJsonArr{
+				{Type: "num", Pattern: `(\d+)`},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			// ambiguous and left-recursive
+			"E": lr1grammar.NewProductionsJson([]string{"E", "+", "E"}, []string{"E", "*", "E"}, []string{"P"}),
+			// right-recursive through a nullable prefix
+			"P": lr1grammar.NewProductionsJson([]string{"SIGNS", "num"}, []string{"(", "E", ")"}),
+			"SIGNS": lr1grammar.NewProductionsJson([]string{"SIGNS", "+"}, []string{"EPSILON"}),
+		},
+		StartSymbol: "E",
+	}
+	_, err := lr1parser.NewParser(config)
+	if (err == nil) {
+		t.Fatal("expected the grammar not to be LR(1)")
+	}
+
+	parser, err := earley.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	// the first rule of E wins on ambiguous input
+	for input, expected := range map[string]string{
+		"1": "((() 1))",
+		"1 + 2 * 3": "(((() 1)) + (((() 2)) * ((() 3))))",
+		"(1) * + + 2": "(((( ((() 1)) ))) * ((((() +) +) 2)))",
+	} {
+		tree, err := parser.ParseString(input)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if (parsertest.Bracketed(tree) != expected) {
+			t.Errorf("%q: expected %s, got %s", input, expected, parsertest.Bracketed(tree))
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"1 +": "unexpected end of input at 1:4, expected '(', '+' or num",
+		"1 2": "unexpected '2' at 1:3, expected '*', '+' or end of input",
+		"(1 + 2": "unexpected end of input at 1:7, expected ')', '*' or '+'",
+	} {
+		_, err := parser.ParseString(input)
+		var syntaxError *lr1parser.SyntaxError
+		if (!errors.As(err, &syntaxError)) {
+			t.Fatalf("%q: expected a syntax error, got: %v", input, err)
+		}
+		if (err.Error() != expected) {
+			t.Errorf("%q: expected %q, got %q", input, expected, err.Error())
+		}
+	}
+}
+
+func TestParserHandlesCyclicGrammar(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "a", Pattern: "(a)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"S"}, []string{"S", "S"}, []string{"a"}, []string{"EPSILON"}),
+		},
+		StartSymbol: "S",
+	}
+	parser, err := earley.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{"", "a", "a a a"} {
+		tokens := parser.Lexer.MustTokenize(input)
+		if err := parser.Recognize(*tokens); err != nil {
+			t.Errorf("%q: %v", input, err)
+		}
+		tree, err := parser.ParseTokens(*tokens)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if (len(cst.Leaves(tree)) != len(*tokens) - 1) {
+			t.Errorf("%q: expected every token in the tree, got %s", input, parsertest.Bracketed(tree))
+		}
+	}
+}
+
+func TestParserRejectsTokensWithoutEOF(t *testing.T) {
+	parser, err := earley.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	tokens := *parser.Lexer.MustTokenize("[true]")
+	for _, input := range [][]*lexer.Token{{}, tokens[:len(tokens) - 1]} {
+		if _, err := parser.ParseTokens(input); !errors.Is(err, lr1parser.ErrMissingEOF) {
+			t.Errorf("expected ErrMissingEOF for %d tokens, got: %v", len(input), err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package earley
+
+import (
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"io"
+)
+
+/*
+An Earley parser: parses with any context-free grammar, including left-recursive,
+ambiguous and non-LR(1) ones, without building parsing tables. Slower than the LR(1)
+driver but builds the same trees, which makes it handy for iterating on a grammar
+before it is LR(1).
+*/
+type Parser struct {
+	Lexer *lexer.Lexer
+	Grammar *lr1grammar.Grammar
+}
+
+func NewParser(config lr1grammar.GrammarConfigJson) (*Parser, error) {
+	// augmented like the LR(1) grammar so that trees carry the same rule ids
+	lex, grammar, err := lr1grammar.NewLexerAndGrammar(config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{lex, grammar}, nil
+}
+
+func NewParserFromJsonConfig(path string) (*Parser, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewParser(config)
+}
+
+// Parses an input into a concrete syntax tree rooted at the start symbol.
+func (p *Parser) ParseString(input string) (*cst.Node, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ParseTokens(*tokens)
+}
+
+func (p *Parser) ParseReader(reader io.Reader) (*cst.Node, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf(`Error reading input: %w`, err)
+	}
+
+	return p.ParseString(string(bytes))
+}
+
+/*
+Parses a token stream terminated by an EOF token. Returns an `*lr1parser.SyntaxError`
+at the first token no derivation can continue with, `lr1parser.ErrMissingEOF` if the
+stream has no EOF token.
+
+An ambiguous input yields one of its trees: the first production rule of a
+non-terminal that derives its span wins, see `chart.build`.
+*/
+func (p *Parser) ParseTokens(tokens []*lexer.Token) (*cst.Node, error) {
+	chart, err := p.recognize(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return chart.tree(), nil
+}
+
+// Checks that a token stream is in the language of the grammar, without building a tree.
+func (p *Parser) Recognize(tokens []*lexer.Token) error {
+	_, err := p.recognize(tokens)
+	return err
+}
@@ -0,0 +1,99 @@
+package earley
+
+import (
+	"interpreters/internal/cst"
+	"interpreters/internal/parser/lr1parser"
+	"slices"
+)
+
+type span struct {
+	symbol int
+	start int
+	end int
+}
+
+// Builds the tree of the start symbol over the whole input from a filled chart.
+func (c *chart) tree() *cst.Node {
+	builder := &treeBuilder{c, lr1parser.TreeBuilder{}, make(map[span]any), make(map[span]bool)}
+	value, _ := builder.build(span{c.start, 0, len(c.tokens) - 1})
+	return builder.Root(value)
+}
+
+type treeBuilder struct {
+	chart *chart
+	lr1parser.TreeBuilder
+	built map[span]any
+	// spans being built, to break cycles such as `A -> A`
+	building map[span]bool
+}
+
+/*
+Builds the value of a symbol deriving a span of tokens. Production rules are tried
+in rule id order and RHS symbols from the last one, each taking the shortest span
+left that still completes the rule. Fails only when every derivation of the span
+goes back through a span being built.
+*/
+func (b *treeBuilder) build(s span) (any, bool) {
+	grammar := b.chart.grammar
+	if (grammar.Symbols.IsTerminal(s.symbol)) {
+		return b.Shift(b.chart.tokens[s.start]), true
+	}
+	if value, exists := b.built[s]; exists {
+		return value, true
+	}
+	if (b.building[s]) {
+		return nil, false
+	}
+	b.building[s] = true
+	defer delete(b.building, s)
+
+	rules := slices.Clone(b.chart.sets[s.end].completed[[2]int{s.symbol, s.start}])
+	slices.Sort(rules)
+	for _, rule := range rules {
+		children, ok := b.split(rule, len(grammar.RuleRHS(rule)), s.start, s.end)
+		if (!ok) {
+			continue
+		}
+		value, err := b.Reduce(rule, grammar.ProductionRules[uint(rule)], children)
+		if (err != nil) {
+			continue
+		}
+		b.built[s] = value
+		return value, true
+	}
+	return nil, false
+}
+
+// Builds the first `dot` RHS symbols of a rule started at `start`, the last of them
+// ending at `end`.
+func (b *treeBuilder) split(rule int, dot int, start int, end int) ([]any, bool) {
+	if (dot == 0) {
+		return []any{}, start == end
+	}
+
+	grammar := b.chart.grammar
+	symbol := grammar.RuleRHS(rule)[dot - 1]
+	for middle := end; middle >= start; middle-- {
+		if (!b.chart.sets[middle].index[item{rule, dot - 1, start}]) {
+			continue
+		}
+		if (grammar.Symbols.IsTerminal(symbol)) {
+			if (middle != end - 1) {
+				continue
+			}
+		} else if (len(b.chart.sets[end].completed[[2]int{symbol, middle}]) == 0) {
+			continue
+		}
+
+		child, ok := b.build(span{symbol, middle, end})
+		if (!ok) {
+			continue
+		}
+		children, ok := b.split(rule, dot - 1, start, middle)
+		if (!ok) {
+			continue
+		}
+		return append(children, child), true
+	}
+	return nil, false
+}
@@ -2,6 +2,7 @@ package firstfollow
 
 import (
 	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
 	"interpreters/utilities/sets"
 )
 
@@ -24,9 +25,14 @@ func ComputeFIRSTSets(grammar *lr1grammar.Grammar) map[string]sets.Set[string] {
 		newSymbolFIRSTSet := symbolFIRSTSet.Clone()
 
 		for _, productionRule := range productionRules {
-			for _, ruleSymbol := range productionRule.Production {
+			for idx, ruleSymbol := range productionRule.Production {
 				// ruleSymbol is the current leading symbol: FIRST(ruleSymbol) in FIRST(symbol)
 				ruleSymbolFIRSTSet := FIRSTSets[ruleSymbol]
+				if (idx < len(productionRule.Production) - 1 && ruleSymbolFIRSTSet.Has(symbols.Epsilon)) {
+					// Epsilon is only in FIRST(symbol) if the whole production derives it
+					ruleSymbolFIRSTSet = ruleSymbolFIRSTSet.Clone()
+					ruleSymbolFIRSTSet.Delete(symbols.Epsilon)
+				}
 				newSymbolFIRSTSet = newSymbolFIRSTSet.Union(ruleSymbolFIRSTSet)
 
 				if (!grammar.DerivesEpsilon(ruleSymbol) || grammar.Terminals.Has(ruleSymbol)) {
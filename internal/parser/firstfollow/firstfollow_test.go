@@ -1,7 +1,63 @@
 package firstfollow_test
 
-import "testing"
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/firstfollow"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/utilities/sets"
+	"slices"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func sorted(set sets.Set[string]) []string {
+	items := set.GetItems()
+	slices.Sort(items)
+	return items
+}
 
 func TestFirstFollow(t *testing.T) {
-	
-}
\ No newline at end of file
+	grammar := lr1grammar.MustNewGrammar(lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "a", Pattern: "(a)"},
+				{Type: "b", Pattern: "(b)"},
+				{Type: "c", Pattern: "(c)"},
+				{Type: "d", Pattern: "(d)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"A", "B", "C", "d"}, []string{"B", "c"}),
+			"A": lr1grammar.NewProductionsJson([]string{"a"}),
+			"B": lr1grammar.NewProductionsJson([]string{"b"}, []string{"EPSILON"}),
+			"C": lr1grammar.NewProductionsJson([]string{"c"}, []string{"EPSILON"}),
+		},
+		StartSymbol: "S",
+	})
+	FIRSTSets, FOLLOWSets := firstfollow.ComputeFIRSTandFOLLOW(grammar)
+
+	expectedFIRST := map[string][]string{
+		"S": {"a", "b", "c"},
+		"A": {"a"},
+		"B": {"EPSILON", "b"},
+		"C": {"EPSILON", "c"},
+	}
+	// FOLLOW(A) reaches past both nullable symbols after it
+	expectedFOLLOW := map[string][]string{
+		"S": {"$"},
+		"A": {"b", "c", "d"},
+		"B": {"c", "d"},
+		"C": {"d"},
+	}
+	for nonTerminal, expected := range expectedFIRST {
+		if diff := deep.Equal(sorted(FIRSTSets[nonTerminal]), expected); diff != nil {
+			t.Errorf("FIRST(%s): %v", nonTerminal, diff)
+		}
+	}
+	for nonTerminal, expected := range expectedFOLLOW {
+		if diff := deep.Equal(sorted(FOLLOWSets[nonTerminal]), expected); diff != nil {
+			t.Errorf("FOLLOW(%s): %v", nonTerminal, diff)
+		}
+	}
+}
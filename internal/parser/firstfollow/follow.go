@@ -35,23 +35,21 @@ func ComputeFOLLOWSets(grammar *lr1grammar.Grammar, FIRSTSets map[string]sets.Se
 					continue
 				}
 
-				// If 'symbol' is at the end of the production, add FOLLOW(LHS) to FOLLOW(symbol)
-				if idx == len(production)-1 {
-					newSymbolFOLLOWSet = newSymbolFOLLOWSet.Union(nonTerminalFOLLOWSet)
-				} else {
-					// The symbol that follows 'symbol'
-					nextSymbol := production[idx+1]
-					nextSymbolFIRSTSet := FIRSTSets[nextSymbol]
-
-					// Add FIRST(nextSymbol) to FOLLOW(symbol)
-					newSymbolFOLLOWSet = newSymbolFOLLOWSet.Union(nextSymbolFIRSTSet)
-
-					// If nextSymbol can derive epsilon (and is a NonTerminal),
-					// then FOLLOW(LHS) also goes into FOLLOW(symbol)
-					if grammar.NonTerminals.Has(nextSymbol) && grammar.DerivesEpsilon(nextSymbol) {
-						newSymbolFOLLOWSet = newSymbolFOLLOWSet.Union(nonTerminalFOLLOWSet)
+				// FIRST of every following symbol up to the first one that does not
+				// derive Epsilon goes into FOLLOW(symbol)
+				rest := idx + 1
+				for ; rest < len(production); rest++ {
+					nextSymbol := production[rest]
+					newSymbolFOLLOWSet = newSymbolFOLLOWSet.Union(FIRSTSets[nextSymbol])
+					if !grammar.DerivesEpsilon(nextSymbol) {
+						break
 					}
 				}
+
+				// If everything after 'symbol' derives Epsilon, add FOLLOW(LHS) to FOLLOW(symbol)
+				if rest == len(production) {
+					newSymbolFOLLOWSet = newSymbolFOLLOWSet.Union(nonTerminalFOLLOWSet)
+				}
 			}
 		}
 
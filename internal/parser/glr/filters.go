@@ -0,0 +1,82 @@
+package glr
+
+/*
+Chooses between the derivations of an ambiguous node: returns the alternatives to
+keep, a subset of `alternatives`. Returning none rejects the node, and every tree
+going through it.
+*/
+type Filter func (node *ForestNode, alternatives []*PackedNode) []*PackedNode
+
+/*
+Applies filters to the ambiguous nodes reachable from the root, top-down, so that
+subtrees only reachable through a rejected alternative are left alone. The forest is
+filtered in place; filters run in the order given on each node.
+*/
+func (f *Forest) Disambiguate(filters ...Filter) {
+	if (f.Root == nil) {
+		return
+	}
+	visited := map[*ForestNode]bool{f.Root: true}
+	queue := []*ForestNode{f.Root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, filter := range filters {
+			if (node.IsAmbiguous()) {
+				node.Alternatives = filter(node, node.Alternatives)
+			}
+		}
+		for _, alternative := range node.Alternatives {
+			for _, child := range alternative.Children {
+				if (!visited[child]) {
+					visited[child] = true
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+}
+
+// Keeps the alternatives matching a predicate, or all of them if none match.
+func Prefer(predicate func (node *ForestNode, alternative *PackedNode) bool) Filter {
+	return func (node *ForestNode, alternatives []*PackedNode) []*PackedNode {
+		preferred := []*PackedNode{}
+		for _, alternative := range alternatives {
+			if (predicate(node, alternative)) {
+				preferred = append(preferred, alternative)
+			}
+		}
+		if (len(preferred) == 0) {
+			return alternatives
+		}
+		return preferred
+	}
+}
+
+// Prefers derivations by the given rule, e.g. the rule of a lower-precedence operator
+// at the top of an expression.
+func PreferRule(ruleId int) Filter {
+	return Prefer(func (_ *ForestNode, alternative *PackedNode) bool {
+		return alternative.RuleId == ruleId
+	})
+}
+
+// Prefers derivations whose last child does not derive the same symbol by `ruleId`:
+// binary operators defined by that rule associate to the left.
+func LeftAssociative(ruleId int) Filter {
+	return Prefer(func (node *ForestNode, alternative *PackedNode) bool {
+		if (alternative.RuleId != ruleId || len(alternative.Children) == 0) {
+			return true
+		}
+		last := alternative.Children[len(alternative.Children) - 1]
+		if (last.Symbol != node.Symbol) {
+			return true
+		}
+		for _, nested := range last.Alternatives {
+			if (nested.RuleId == ruleId) {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,230 @@
+package glr
+
+import (
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"math"
+	"slices"
+)
+
+/*
+Shared packed parse forest (SPPF) of an input: every parse tree of the input, with the
+subtrees common to several of them stored once. There is a single `ForestNode` per
+symbol and span of tokens, and each of its `Alternatives` is one way to derive it.
+*/
+type Forest struct {
+	Root *ForestNode
+	grammar *lr1grammar.Grammar
+	tokens []*lexer.Token
+	nodes map[forestKey]*ForestNode
+}
+
+type forestKey struct {
+	symbol string
+	start int
+	end int
+}
+
+type ForestNode struct {
+	Symbol string
+	// span of tokens, `Start` inclusive and `End` exclusive
+	Start int
+	End int
+	// the shifted token of a terminal node, `nil` for non-terminals
+	Token *lexer.Token
+	Alternatives []*PackedNode
+}
+
+// One derivation of a non-terminal node: the production rule and a node per RHS symbol.
+type PackedNode struct {
+	RuleId int
+	Children []*ForestNode
+}
+
+func newForest(grammar *lr1grammar.Grammar, tokens []*lexer.Token) *Forest {
+	return &Forest{nil, grammar, tokens, make(map[forestKey]*ForestNode)}
+}
+
+func (f *Forest) symbolNode(symbol string, start int, end int) *ForestNode {
+	key := forestKey{symbol, start, end}
+	node, exists := f.nodes[key]
+	if (!exists) {
+		node = &ForestNode{Symbol: symbol, Start: start, End: end}
+		f.nodes[key] = node
+	}
+	return node
+}
+
+func (f *Forest) leaf(index int) *ForestNode {
+	node := f.symbolNode(f.tokens[index].Type, index, index + 1)
+	node.Token = f.tokens[index]
+	return node
+}
+
+func (node *ForestNode) IsTerminal() bool {
+	return node.Token != nil
+}
+
+func (node *ForestNode) IsAmbiguous() bool {
+	return len(node.Alternatives) > 1
+}
+
+func (node *ForestNode) addAlternative(ruleId int, children []*ForestNode) {
+	for _, alternative := range node.Alternatives {
+		if (alternative.RuleId == ruleId && slices.Equal(alternative.Children, children)) {
+			return
+		}
+	}
+	node.Alternatives = append(node.Alternatives, &PackedNode{ruleId, children})
+}
+
+// Number of parse trees in the forest, saturating at `math.MaxInt`. Cyclic
+// derivations (`A -> A`) are not counted.
+func (f *Forest) CountTrees() int {
+	counts := make(map[*ForestNode]int)
+	var count func (node *ForestNode) int
+	count = func (node *ForestNode) int {
+		if (node.IsTerminal()) {
+			return 1
+		}
+		if total, exists := counts[node]; exists {
+			return total
+		}
+		// a node on the current path contributes no finite tree
+		counts[node] = 0
+
+		total := 0
+		for _, alternative := range node.Alternatives {
+			product := 1
+			for _, child := range alternative.Children {
+				product = saturatingMul(product, count(child))
+			}
+			total = saturatingAdd(total, product)
+		}
+		counts[node] = total
+		return total
+	}
+	return count(f.Root)
+}
+
+func saturatingAdd(a int, b int) int {
+	if (a > math.MaxInt - b) {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func saturatingMul(a int, b int) int {
+	if (a != 0 && b > math.MaxInt / a) {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// Builds up to `limit` of the parse trees in the forest, shaped like the trees of
+// the LR(1) driver.
+func (f *Forest) Trees(limit int) []*cst.Node {
+	builder := lr1parser.TreeBuilder{}
+	building := make(map[*ForestNode]bool)
+
+	var values func (node *ForestNode) []any
+	values = func (node *ForestNode) []any {
+		if (node.IsTerminal()) {
+			return []any{builder.Shift(node.Token)}
+		}
+		if (building[node]) {
+			return nil
+		}
+		building[node] = true
+		defer delete(building, node)
+
+		result := []any{}
+		for _, alternative := range node.Alternatives {
+			rule := f.grammar.ProductionRules[uint(alternative.RuleId)]
+			for _, children := range combinations(alternative.Children, values, limit - len(result)) {
+				value, err := builder.Reduce(alternative.RuleId, rule, children)
+				if (err == nil) {
+					result = append(result, value)
+				}
+			}
+			if (len(result) >= limit) {
+				break
+			}
+		}
+		return result
+	}
+
+	trees := []*cst.Node{}
+	if (limit <= 0 || f.Root == nil) {
+		return trees
+	}
+	for _, value := range values(f.Root) {
+		trees = append(trees, builder.Root(value))
+	}
+	return trees
+}
+
+// Up to `limit` ways to pick one value for each child.
+func combinations(children []*ForestNode, values func (*ForestNode) []any, limit int) [][]any {
+	result := [][]any{{}}
+	for _, child := range children {
+		childValues := values(child)
+		next := [][]any{}
+		for _, prefix := range result {
+			for _, value := range childValues {
+				if (len(next) == limit) {
+					break
+				}
+				next = append(next, append(slices.Clone(prefix), value))
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// The only parse tree in the forest. An ambiguous forest is an error naming the first
+// ambiguous node, see `Disambiguate`.
+func (f *Forest) Tree() (*cst.Node, error) {
+	if node := f.firstAmbiguity(); node != nil {
+		return nil, f.ambiguityError(node)
+	}
+	trees := f.Trees(1)
+	if (len(trees) == 0) {
+		return nil, fmt.Errorf(`No parse tree left in the forest`)
+	}
+	return trees[0], nil
+}
+
+// First ambiguous node reachable from the root, breadth first.
+func (f *Forest) firstAmbiguity() *ForestNode {
+	if (f.Root == nil) {
+		return nil
+	}
+	visited := map[*ForestNode]bool{f.Root: true}
+	queue := []*ForestNode{f.Root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if (node.IsAmbiguous()) {
+			return node
+		}
+		for _, alternative := range node.Alternatives {
+			for _, child := range alternative.Children {
+				if (!visited[child]) {
+					visited[child] = true
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Forest) ambiguityError(node *ForestNode) error {
+	token := f.tokens[min(node.Start, len(f.tokens) - 1)]
+	return fmt.Errorf(`ambiguous input: %d alternatives for %s at %d:%d`, len(node.Alternatives), node.Symbol, token.Line, token.Col)
+}
@@ -0,0 +1,204 @@
+package glr_test
+
+import (
+	"errors"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/glr"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/parsertest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func arithmeticConfig() lr1grammar.GrammarConfigJson {
+	return lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "+", Pattern: `(\+)`},
+				{Type: "*", Pattern: `(\*)`},
+			},
+			GenericTokens: lexer.TokenConfigJsonArr{
+				{Type: "num", Pattern: `(\d+)`},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"E": lr1grammar.NewProductionsJson([]string{"E", "+", "E"}, []string{"E", "*", "E"}, []string{"num"}),
+		},
+		StartSymbol: "E",
+	}
+}
+
+func TestParserBuildsForestOfAmbiguousInput(t *testing.T) {
+	parser, err := glr.NewParser(arithmeticConfig())
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (len(parser.Table.Conflicts) == 0) {
+		t.Fatal("expected the grammar to have conflicts")
+	}
+
+	forest, err := parser.ParseString("1 + 2 * 3")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (forest.CountTrees() != 2) {
+		t.Errorf("expected 2 trees, got %d", forest.CountTrees())
+	}
+
+	trees := []string{}
+	for _, tree := range forest.Trees(10) {
+		trees = append(trees, parsertest.Bracketed(tree))
+	}
+	if diff := deep.Equal(trees, []string{"(((1) + (2)) * (3))", "((1) + ((2) * (3)))"}); diff != nil {
+		t.Error(diff)
+	}
+
+	_, err = forest.Tree()
+	if (err == nil || err.Error() != "ambiguous input: 2 alternatives for E at 1:1") {
+		t.Errorf("expected an ambiguity error, got: %v", err)
+	}
+
+	// the lowest-precedence operator goes at the top
+	plus, _ := parser.Grammar.GetProductionId("E", []string{"E", "+", "E"})
+	forest.Disambiguate(glr.PreferRule(plus))
+	tree, err := forest.Tree()
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (parsertest.Bracketed(tree) != "((1) + ((2) * (3)))") {
+		t.Errorf("unexpected tree: %s", parsertest.Bracketed(tree))
+	}
+}
+
+func TestForestGrowsWithAmbiguity(t *testing.T) {
+	parser, err := glr.NewParser(arithmeticConfig())
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	// Catalan numbers: the ways to bracket n operators
+	for operators, expected := range []int{1, 1, 2, 5, 14, 42} {
+		input := "1" + strings.Repeat(" + 1", operators)
+		forest, err := parser.ParseString(input)
+		if (err != nil) {
+			t.Fatal(err)
+		}
+		if (forest.CountTrees() != expected) {
+			t.Errorf("%q: expected %d trees, got %d", input, expected, forest.CountTrees())
+		}
+		if (len(forest.Trees(3)) != min(expected, 3)) {
+			t.Errorf("%q: expected the number of trees to be limited", input)
+		}
+	}
+
+	plus, _ := parser.Grammar.GetProductionId("E", []string{"E", "+", "E"})
+	forest, _ := parser.ParseString("1 + 2 + 3 + 4")
+	forest.Disambiguate(glr.LeftAssociative(plus))
+	tree, err := forest.Tree()
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (parsertest.Bracketed(tree) != "((((1) + (2)) + (3)) + (4))") {
+		t.Errorf("unexpected tree: %s", parsertest.Bracketed(tree))
+	}
+}
+
+func TestParserHandlesNonLR1Grammar(t *testing.T) {
+	// reduce/reduce conflict on x: only the next token tells A from B
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "a", Pattern: "(a)"},
+				{Type: "x", Pattern: "(x)"},
+				{Type: "y", Pattern: "(y)"},
+				{Type: "z", Pattern: "(z)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"A", "x", "y"}, []string{"B", "x", "z"}),
+			"A": lr1grammar.NewProductionsJson([]string{"a", "X"}),
+			"B": lr1grammar.NewProductionsJson([]string{"a", "X"}),
+			"X": lr1grammar.NewProductionsJson([]string{"x"}, []string{"EPSILON"}),
+		},
+		StartSymbol: "S",
+	}
+	_, err := lr1parser.NewParser(config)
+	if (err == nil) {
+		t.Fatal("expected the grammar not to be LR(1)")
+	}
+
+	parser, err := glr.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	for input, expected := range map[string]string{
+		"a x z": "((a ()) x z)",
+		"a x x y": "((a (x)) x y)",
+	} {
+		forest, err := parser.ParseString(input)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		tree, err := forest.Tree()
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if (parsertest.Bracketed(tree) != expected) {
+			t.Errorf("%q: expected %s, got %s", input, expected, parsertest.Bracketed(tree))
+		}
+	}
+
+	_, err = parser.ParseString("a x")
+	var syntaxError *lr1parser.SyntaxError
+	if (!errors.As(err, &syntaxError)) {
+		t.Fatalf("expected a syntax error, got: %v", err)
+	}
+	if (err.Error() != "unexpected end of input at 1:4, expected x, y or z") {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
+
+func TestParserMatchesLR1Trees(t *testing.T) {
+	lr1, err := lr1parser.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	parser, err := glr.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	input := `{ "a": [1, 2, { "b": null }], "c": true }`
+	expected, err := lr1.ParseString(input)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	forest, err := parser.ParseString(input)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	tree, err := forest.Tree()
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(tree, expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestParserRejectsTokensWithoutEOF(t *testing.T) {
+	parser, err := glr.NewParser(arithmeticConfig())
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	tokens := *parser.Lexer.MustTokenize("1 + 2")
+	for _, input := range [][]*lexer.Token{{}, tokens[:len(tokens) - 1]} {
+		if _, err := parser.ParseTokens(input); !errors.Is(err, glr.ErrMissingEOF) {
+			t.Errorf("expected ErrMissingEOF for %d tokens, got: %v", len(input), err)
+		}
+	}
+}
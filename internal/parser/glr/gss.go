@@ -0,0 +1,218 @@
+package glr
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/lr1parsingtable"
+	"slices"
+)
+
+// Same as `lr1parser.ErrMissingEOF`.
+var ErrMissingEOF = lr1parser.ErrMissingEOF
+
+/*
+Node of the graph-structured stack (GSS). Stacks of forked parsers share their
+common nodes: a node links to the nodes below it on every stack it is part of, and
+each link is labelled with the forest node of the symbol between both.
+*/
+type gssNode struct {
+	state int
+	// index of the token that was the lookahead when the node was created
+	level int
+	links []*gssLink
+}
+
+type gssLink struct {
+	to *gssNode
+	value *ForestNode
+}
+
+func (node *gssNode) linkTo(other *gssNode) *gssLink {
+	for _, link := range node.links {
+		if (link.to == other) {
+			return link
+		}
+	}
+	return nil
+}
+
+type reduction struct {
+	node *gssNode
+	ruleId int
+	// only reduce along paths that go through this link, all paths if `nil`
+	via *gssLink
+}
+
+type shift struct {
+	node *gssNode
+	state int
+}
+
+// The GSS nodes created for one lookahead token and the actions left to run on them.
+type gssLevel struct {
+	parser *Parser
+	forest *Forest
+	index int
+	token *lexer.Token
+
+	// at most one node per state, kept in creation order to stay deterministic
+	nodes map[int]*gssNode
+	order []*gssNode
+	pending []reduction
+	shifts []shift
+	accepted *ForestNode
+}
+
+/*
+Runs the GLR driver over a token stream terminated by an EOF token. Returns
+`ErrMissingEOF` if the stream runs out before its EOF token.
+
+Each token is processed in two phases: every parser on the frontier reduces as far
+as it can (forking on conflicts), then all of them shift the token. A new link to an
+existing node of the level opens new reduction paths through that link for nodes
+already processed, which are queued again (Farshi's correction for Epsilon rules).
+*/
+func (p *Parser) ParseTokens(tokens []*lexer.Token) (*Forest, error) {
+	forest := newForest(p.Grammar, tokens)
+	frontier := []*gssNode{{0, 0, nil}}
+
+	for idx, token := range tokens {
+		level := &gssLevel{parser: p, forest: forest, index: idx, token: token, nodes: make(map[int]*gssNode)}
+		for _, node := range frontier {
+			level.addNode(node)
+		}
+		level.reduce()
+
+		if (level.accepted != nil) {
+			forest.Root = level.accepted
+			return forest, nil
+		}
+		if (len(level.shifts) == 0) {
+			return nil, level.syntaxError(frontier)
+		}
+		frontier = level.shift()
+	}
+
+	// the EOF token is never shifted, so only a stream without one gets here
+	return nil, ErrMissingEOF
+}
+
+func (level *gssLevel) addNode(node *gssNode) {
+	level.nodes[node.state] = node
+	level.order = append(level.order, node)
+	level.queueActions(node, nil)
+}
+
+// Queues the actions of a node on the lookahead. With `via` set, only queues the
+// reductions that can go through that link.
+func (level *gssLevel) queueActions(node *gssNode, via *gssLink) {
+	for _, action := range level.parser.Table.Actions(node.state, level.token.Type) {
+		switch action.ActionVerb() {
+		case lr1parsingtable.SHIFT:
+			if (via == nil) {
+				level.shifts = append(level.shifts, shift{node, action.NextState()})
+			}
+		case lr1parsingtable.REDUCE:
+			rule := level.parser.Grammar.ProductionRules[uint(action.ReduceByRule())]
+			if (via == nil || rule.Length() > 0) {
+				level.pending = append(level.pending, reduction{node, action.ReduceByRule(), via})
+			}
+		case lr1parsingtable.ACCEPT:
+			// the node holds `G' -> S •`: its link to the bottom of the stack holds S
+			if (via == nil && level.accepted == nil) {
+				level.accepted = node.links[0].value
+			}
+		}
+	}
+}
+
+func (level *gssLevel) reduce() {
+	for len(level.pending) > 0 {
+		r := level.pending[0]
+		level.pending = level.pending[1:]
+
+		rule := level.parser.Grammar.ProductionRules[uint(r.ruleId)]
+		for _, path := range paths(r.node, rule.Length(), r.via) {
+			end := r.node
+			children := make([]*ForestNode, len(path))
+			for idx, link := range path {
+				children[len(path) - 1 - idx] = link.value
+				end = link.to
+			}
+
+			symbolNode := level.forest.symbolNode(rule.NonTerminal, end.level, level.index)
+			symbolNode.addAlternative(r.ruleId, children)
+
+			target := level.parser.Table.Goto(end.state, rule.NonTerminal).NextState()
+			node, exists := level.nodes[target]
+			if (!exists) {
+				level.addNode(&gssNode{target, level.index, []*gssLink{{end, symbolNode}}})
+				continue
+			}
+			if (node.linkTo(end) != nil) {
+				// same symbol and span: the new derivation was added to its forest node
+				continue
+			}
+			link := &gssLink{end, symbolNode}
+			node.links = append(node.links, link)
+			for _, other := range level.order {
+				level.queueActions(other, link)
+			}
+		}
+	}
+}
+
+// Shifts the lookahead on every parser that can, merging parsers that reach the same
+// state. Returns the next frontier.
+func (level *gssLevel) shift() []*gssNode {
+	leaf := level.forest.leaf(level.index)
+	nodes := make(map[int]*gssNode)
+	frontier := []*gssNode{}
+	for _, s := range level.shifts {
+		node, exists := nodes[s.state]
+		if (!exists) {
+			node = &gssNode{s.state, level.index + 1, nil}
+			nodes[s.state] = node
+			frontier = append(frontier, node)
+		}
+		if (node.linkTo(s.node) == nil) {
+			node.links = append(node.links, &gssLink{s.node, leaf})
+		}
+	}
+	return frontier
+}
+
+// Reports the lookahead as unexpected, expecting what any of the parsers on the
+// frontier expected.
+func (level *gssLevel) syntaxError(frontier []*gssNode) error {
+	expected := []string{}
+	for _, terminal := range level.parser.Table.ActionSymbols() {
+		for _, node := range level.order {
+			if (slices.Contains(level.parser.Table.ExpectedTerminals(node.state), terminal)) {
+				expected = append(expected, terminal)
+				break
+			}
+		}
+	}
+	return lr1parser.NewSyntaxError(level.parser.Grammar.DisplayName, level.token, expected)
+}
+
+// All paths of `length` links down from a node. With `via` set, only the paths that
+// go through that link.
+func paths(node *gssNode, length int, via *gssLink) [][]*gssLink {
+	result := [][]*gssLink{}
+	var walk func (node *gssNode, path []*gssLink, throughVia bool)
+	walk = func (node *gssNode, path []*gssLink, throughVia bool) {
+		if (len(path) == length) {
+			if (via == nil || throughVia) {
+				result = append(result, slices.Clone(path))
+			}
+			return
+		}
+		for _, link := range node.links {
+			walk(link.to, append(path, link), throughVia || link == via)
+		}
+	}
+	walk(node, nil, false)
+	return result
+}
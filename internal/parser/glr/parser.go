@@ -0,0 +1,62 @@
+package glr
+
+import (
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parsingtable"
+	"io"
+)
+
+/*
+A generalized LR parser: like `lr1parser.Parser` but the parsing table may have
+conflicts. The driver forks on conflicting cells and returns every parse of the input
+as a shared packed parse `Forest`.
+*/
+type Parser struct {
+	Lexer *lexer.Lexer
+	Grammar *lr1grammar.Grammar
+	Table *lr1parsingtable.ParsingTable
+}
+
+func NewParser(config lr1grammar.GrammarConfigJson) (*Parser, error) {
+	lex, grammar, err := lr1grammar.NewLexerAndGrammar(config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := lr1parsingtable.NewGLRParsingTable(grammar)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{lex, grammar, table}, nil
+}
+
+func NewParserFromJsonConfig(path string) (*Parser, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewParser(config)
+}
+
+// Parses an input into the forest of all its parse trees.
+func (p *Parser) ParseString(input string) (*Forest, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ParseTokens(*tokens)
+}
+
+func (p *Parser) ParseReader(reader io.Reader) (*Forest, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf(`Error reading input: %w`, err)
+	}
+
+	return p.ParseString(string(bytes))
+}
@@ -0,0 +1,227 @@
+package grammartransform_test
+
+import (
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/grammartransform"
+	"interpreters/internal/parser/ll1"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/parsertest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// The production rules of a grammar in rule id order.
+func productions(grammar *lr1grammar.Grammar) []string {
+	rules := []string{}
+	for ruleId := 0; ruleId < len(grammar.ProductionRules); ruleId++ {
+		rule := grammar.ProductionRules[uint(ruleId)]
+		rules = append(rules, rule.NonTerminal + " -> " + strings.Join(rule.Production, " "))
+	}
+	return rules
+}
+
+// Parses an input with an LL(1) parser for the transformed grammar and restores the tree.
+func parseAndRestore(t *testing.T, terminals lexer.LexerConfigJson, input string, results ...*grammartransform.Result) *cst.Node {
+	t.Helper()
+	last := results[len(results) - 1]
+	parser, err := ll1.NewParser(last.Config(terminals))
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	tree, err := parser.ParseString(input)
+	if (err != nil) {
+		t.Fatalf("%q: %v", input, err)
+	}
+	for idx := len(results) - 1; idx >= 0; idx-- {
+		tree, err = results[idx].RestoreTree(tree)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+	}
+	return tree
+}
+
+func TestEliminateDirectLeftRecursion(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: parsertest.SymbolTokens("+", "*", "(", ")", "num"),
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"E": lr1grammar.NewProductionsJson([]string{"E", "+", "T"}, []string{"T"}),
+			"T": lr1grammar.NewProductionsJson([]string{"T", "*", "F"}, []string{"F"}),
+			"F": lr1grammar.NewProductionsJson([]string{"(", "E", ")"}, []string{"num"}),
+		},
+		StartSymbol: "E",
+	}
+	config.Terminals.GenericTokens = lexer.TokenConfigJsonArr{{Type: "num", Pattern: `(\d+)`}}
+	config.Terminals.SymbolTokens = config.Terminals.SymbolTokens[:4]
+
+	result, err := grammartransform.EliminateLeftRecursion(lr1grammar.MustNewGrammar(config))
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"E -> T E'",
+		"E' -> + T E'",
+		"E' -> EPSILON",
+		"F -> ( E )",
+		"F -> num",
+		"T -> F T'",
+		"T' -> * F T'",
+		"T' -> EPSILON",
+	}
+	if diff := deep.Equal(productions(result.Grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(result.Origins, map[string]string{"E'": "E", "T'": "T"}); diff != nil {
+		t.Error(diff)
+	}
+
+	tree := parseAndRestore(t, config.Terminals, "1 + 2 * (3 + 4) * 5", result)
+	expectedTree := "(E (E (T (F 1))) + (T (T (T (F 2)) * (F ( (E (E (T (F 3))) + (T (F 4))) ))) * (F 5)))"
+	if (parsertest.Shape(tree) != expectedTree) {
+		t.Errorf("expected %s, got %s", expectedTree, parsertest.Shape(tree))
+	}
+	ruleId, _ := result.Original.GetProductionId("E", []string{"E", "+", "T"})
+	if (tree.RuleId != ruleId) {
+		t.Errorf("expected the rule id of the original grammar, got %d", tree.RuleId)
+	}
+}
+
+func TestEliminateIndirectLeftRecursion(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: parsertest.SymbolTokens("a", "b", "c", "d"),
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"A", "a"}, []string{"b"}),
+			"A": lr1grammar.NewProductionsJson([]string{"A", "c"}, []string{"S", "d"}, []string{"EPSILON"}),
+		},
+		StartSymbol: "S",
+	}
+	result, err := grammartransform.EliminateLeftRecursion(lr1grammar.MustNewGrammar(config))
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	// A is expanded into S
+	expected := []string{
+		"A' -> c A'",
+		"A' -> EPSILON",
+		"S -> A' a S'",
+		"S -> b S'",
+		"S' -> d A' a S'",
+		"S' -> EPSILON",
+	}
+	if diff := deep.Equal(productions(result.Grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+
+	lr1, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	for _, input := range []string{"b", "a", "b d c a", "c c a d a", "b d a d c a"} {
+		expectedTree, err := lr1.ParseString(input)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		tree := parseAndRestore(t, config.Terminals, input, result)
+		if (parsertest.Shape(tree) != parsertest.Shape(expectedTree)) {
+			t.Errorf("%q: expected %s, got %s", input, parsertest.Shape(expectedTree), parsertest.Shape(tree))
+		}
+	}
+
+	// left recursion behind a nullable symbol
+	config.NonTerminals = map[string][]lr1grammar.ProductionJson{
+		"S": lr1grammar.NewProductionsJson([]string{"A", "S", "a"}, []string{"b"}),
+		"A": lr1grammar.NewProductionsJson([]string{"c"}, []string{"EPSILON"}),
+	}
+	_, err = grammartransform.EliminateLeftRecursion(lr1grammar.MustNewGrammar(config))
+	if (err == nil || err.Error() != "Left recursion through nullable symbols in S: remove Epsilon productions first") {
+		t.Errorf("expected hidden left recursion to be reported, got: %v", err)
+	}
+}
+
+func TestLeftFactor(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: parsertest.SymbolTokens("a", "b", "c", "d", "e", "f"),
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"a", "b", "c"}, []string{"f"}, []string{"a", "b", "d"}, []string{"a", "e"}),
+		},
+		StartSymbol: "S",
+	}
+	result := grammartransform.LeftFactor(lr1grammar.MustNewGrammar(config))
+	expected := []string{
+		"S -> a S'",
+		"S -> f",
+		"S' -> b S''",
+		"S' -> e",
+		"S'' -> c",
+		"S'' -> d",
+	}
+	if diff := deep.Equal(productions(result.Grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(result.Origins, map[string]string{"S'": "S", "S''": "S"}); diff != nil {
+		t.Error(diff)
+	}
+
+	for input, production := range map[string][]string{
+		"a b d": {"a", "b", "d"},
+		"a e": {"a", "e"},
+		"f": {"f"},
+	} {
+		tree := parseAndRestore(t, config.Terminals, input, result)
+		ruleId, _ := result.Original.GetProductionId("S", production)
+		if (parsertest.Shape(tree) != "(S " + input + ")" || tree.RuleId != ruleId) {
+			t.Errorf("%q: unexpected tree %s for rule %d", input, parsertest.Shape(tree), tree.RuleId)
+		}
+	}
+}
+
+// Chained transformations restore the trees the LR(1) parser builds, annotations included.
+func TestRestoreShapedTree(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: parsertest.SymbolTokens("[", "]", ",", ":"),
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"LIST": {
+				{Symbols: []string{"[", "ITEMS", "]"}, ProductionAnnotations: lr1grammar.ProductionAnnotations{Node: "List", Drop: []string{"[", "]"}}},
+			},
+			"ITEMS": {
+				{Symbols: []string{"ITEMS", ",", "ITEM"}, ProductionAnnotations: lr1grammar.ProductionAnnotations{Inline: true, Drop: []string{","}}},
+				{Symbols: []string{"ITEM"}, ProductionAnnotations: lr1grammar.ProductionAnnotations{Inline: true}},
+			},
+			"ITEM": {
+				{Symbols: []string{"num"}, ProductionAnnotations: lr1grammar.ProductionAnnotations{Node: "Num"}},
+				{Symbols: []string{"num", ":", "num"}, ProductionAnnotations: lr1grammar.ProductionAnnotations{
+					Node: "Pair",
+					Drop: []string{":"},
+					Fields: map[string]int{"key": 0, "value": 2},
+				}},
+			},
+		},
+		StartSymbol: "LIST",
+	}
+	config.Terminals.GenericTokens = lexer.TokenConfigJsonArr{{Type: "num", Pattern: `(\d+)`}}
+
+	lr1, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	withoutRecursion, err := grammartransform.EliminateLeftRecursion(lr1.Grammar)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	factored := grammartransform.LeftFactor(withoutRecursion.Grammar)
+
+	input := "[1, 2: 3, 4]"
+	expected, err := lr1.ParseString(input)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	// the augmented start rule is part of the transformed grammar
+	tree := parseAndRestore(t, config.Terminals, input, withoutRecursion, factored)
+	if diff := deep.Equal(tree.Children[0], expected); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,71 @@
+package grammartransform
+
+import (
+	"interpreters/internal/parser/lr1grammar"
+	"slices"
+)
+
+/*
+Left-factors the rules of every non-terminal: rules sharing their first symbol,
+`A -> α β1 | α β2` where α is their longest common prefix, become `A -> α A'` and
+`A' -> β1 | β2`. Repeats until no two rules of a non-terminal start with the same
+symbol, including the rules of the introduced non-terminals.
+*/
+func LeftFactor(grammar *lr1grammar.Grammar) *Result {
+	rs := newRuleSet(grammar)
+	for idx := 0; idx < len(rs.nonTerminals); idx++ {
+		for rs.factorCommonPrefix(rs.nonTerminals[idx]) {
+		}
+	}
+	return rs.result()
+}
+
+// Factors the first group of rules of a non-terminal sharing their first symbol.
+// Returns false if there is none.
+func (rs *ruleSet) factorCommonPrefix(nonTerminal string) bool {
+	rules := rs.rules[nonTerminal]
+	for idx, rule := range rules {
+		if (len(rule.rhs) == 0) {
+			continue
+		}
+		group := []*workingRule{rule}
+		for _, other := range rules[idx + 1:] {
+			if (len(other.rhs) > 0 && other.rhs[0] == rule.rhs[0]) {
+				group = append(group, other)
+			}
+		}
+		if (len(group) < 2) {
+			continue
+		}
+
+		prefix := rule.rhs
+		for _, other := range group[1:] {
+			length := 0
+			for length < len(prefix) && length < len(other.rhs) && prefix[length] == other.rhs[length] {
+				length++
+			}
+			prefix = prefix[:length]
+		}
+
+		// the tail non-terminal is passed the children of the prefix, and those passed to
+		// this non-terminal, and finishes the node
+		tail := rs.newNonTerminal(nonTerminal)
+		for _, member := range group {
+			rs.rules[tail] = append(rs.rules[tail], &workingRule{tail, slices.Clone(member.rhs[len(prefix):]), member.plan})
+		}
+		passed := rule.plan.width() - len(rule.rhs)
+		factored := &workingRule{nonTerminal, append(slices.Clone(prefix), tail), &plan{-1, make([]*plan, passed + len(prefix)), true}}
+
+		factoredRules := []*workingRule{}
+		for _, other := range rules {
+			if (other == rule) {
+				factoredRules = append(factoredRules, factored)
+			} else if (!slices.Contains(group, other)) {
+				factoredRules = append(factoredRules, other)
+			}
+		}
+		rs.rules[nonTerminal] = factoredRules
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package grammartransform
+
+import (
+	"fmt"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/utilities/bitsets"
+	"slices"
+)
+
+/*
+Removes direct and indirect left recursion with Paull's algorithm. Non-terminals are
+taken in symbol order: rules of a non-terminal starting with an earlier one that can
+lead back to it are expanded with the rules of the earlier one, then direct left recursion
+`A -> A α | β` is replaced by `A -> β A'` and `A' -> α A' | EPSILON`. Rules `A -> A`
+are dropped, and so are the non-terminals left unreachable from the start symbol by
+the expansions.
+
+Left recursion hidden behind nullable symbols, as in `A -> B A x` with B nullable, is
+not removed and makes it fail: remove Epsilon productions first.
+*/
+func EliminateLeftRecursion(grammar *lr1grammar.Grammar) (*Result, error) {
+	rs := newRuleSet(grammar)
+	order := slices.Clone(rs.nonTerminals)
+	reachable := rs.reachable()
+	for idx, nonTerminal := range order {
+		// expansions may start with other earlier non-terminals: repeat until none
+		// leading a rule can derive a form starting with the non-terminal
+		for changed := true; changed; {
+			changed = false
+			for _, earlier := range order[:idx] {
+				if (rs.leftCorners(earlier)[nonTerminal] && rs.substituteLeading(nonTerminal, earlier)) {
+					changed = true
+				}
+			}
+		}
+		rs.eliminateDirectLeftRecursion(nonTerminal)
+	}
+	rs.dropUnreachable(reachable)
+
+	result := rs.result()
+	if nonTerminal, exists := leftRecursiveNonTerminal(result.Grammar); exists {
+		return nil, fmt.Errorf(`Left recursion through nullable symbols in %s: remove Epsilon productions first`, nonTerminal)
+	}
+	return result, nil
+}
+
+// Non-terminals that can start a derivation of `nonTerminal` through the first symbols
+// of the rules. Nullable symbols are not looked through.
+func (rs *ruleSet) leftCorners(nonTerminal string) map[string]bool {
+	corners := make(map[string]bool)
+	pending := []string{nonTerminal}
+	for len(pending) > 0 {
+		current := pending[len(pending) - 1]
+		pending = pending[:len(pending) - 1]
+		for _, rule := range rs.rules[current] {
+			if (len(rule.rhs) > 0 && !corners[rule.rhs[0]]) {
+				corners[rule.rhs[0]] = true
+				pending = append(pending, rule.rhs[0])
+			}
+		}
+	}
+	return corners
+}
+
+// Expands the rules of `nonTerminal` that start with `earlier` with each rule of
+// `earlier`. Returns false if no rule starts with `earlier`.
+func (rs *ruleSet) substituteLeading(nonTerminal string, earlier string) bool {
+	rules := []*workingRule{}
+	substituted := false
+	for _, rule := range rs.rules[nonTerminal] {
+		if (len(rule.rhs) == 0 || rule.rhs[0] != earlier) {
+			rules = append(rules, rule)
+			continue
+		}
+		substituted = true
+		for _, expansion := range rs.rules[earlier] {
+			rhs := append(slices.Clone(expansion.rhs), rule.rhs[1:]...)
+			rules = append(rules, &workingRule{nonTerminal, rhs, rule.plan.substituteFirst(expansion.plan)})
+		}
+	}
+	rs.rules[nonTerminal] = rules
+	return substituted
+}
+
+func (rs *ruleSet) eliminateDirectLeftRecursion(nonTerminal string) {
+	recursive := []*workingRule{}
+	others := []*workingRule{}
+	for _, rule := range rs.rules[nonTerminal] {
+		switch {
+		case len(rule.rhs) == 1 && rule.rhs[0] == nonTerminal:
+			// A -> A derives nothing new
+		case len(rule.rhs) > 0 && rule.rhs[0] == nonTerminal:
+			recursive = append(recursive, rule)
+		default:
+			others = append(others, rule)
+		}
+	}
+	if (len(recursive) == 0) {
+		rs.rules[nonTerminal] = others
+		return
+	}
+
+	// the tail non-terminal is passed the A built so far and wraps it once per α
+	tail := rs.newNonTerminal(nonTerminal)
+	rules := []*workingRule{}
+	for _, rule := range others {
+		rhs := append(slices.Clone(rule.rhs), tail)
+		rules = append(rules, &workingRule{nonTerminal, rhs, &plan{-1, []*plan{rule.plan}, true}})
+	}
+	tailRules := []*workingRule{}
+	for _, rule := range recursive {
+		rhs := append(slices.Clone(rule.rhs[1:]), tail)
+		tailRules = append(tailRules, &workingRule{tail, rhs, &plan{-1, []*plan{rule.plan}, true}})
+	}
+	tailRules = append(tailRules, &workingRule{tail, []string{}, &plan{-1, []*plan{nil}, false}})
+
+	rs.rules[nonTerminal] = rules
+	rs.rules[tail] = tailRules
+}
+
+// A non-terminal deriving a sentential form that starts with itself, if any.
+func leftRecursiveNonTerminal(grammar *lr1grammar.Grammar) (string, bool) {
+	numSymbols := grammar.Symbols.Len()
+
+	// symbols that can start a derivation of each non-terminal
+	corners := make([]bitsets.Bitset, numSymbols)
+	for id := range corners {
+		corners[id] = bitsets.New(numSymbols)
+	}
+	for ruleId := 0; ruleId < len(grammar.ProductionRules); ruleId++ {
+		lhs := grammar.RuleLHS(ruleId)
+		for _, symbol := range grammar.RuleRHS(ruleId) {
+			corners[lhs].Add(symbol)
+			if (!grammar.IsNullable(symbol)) {
+				break
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for id := grammar.Symbols.NumTerminals(); id < numSymbols; id++ {
+			for _, corner := range corners[id].Items() {
+				if (!grammar.Symbols.IsTerminal(corner) && corners[id].UnionWith(corners[corner])) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	for id := grammar.Symbols.NumTerminals(); id < numSymbols; id++ {
+		if (corners[id].Has(id)) {
+			return grammar.Symbols.Name(id), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,339 @@
+package grammartransform
+
+import (
+	"fmt"
+	"interpreters/internal/parser/lr1grammar"
+	"slices"
+	"strings"
+)
+
+// The transformations below change the number of children of the rules, so the trees
+// of the grammars they build cannot be restored: they return the grammar alone.
+
+/*
+Removes Epsilon productions: each rule is replaced by its variants without any subset
+of its nullable symbols, except the empty one. If the start symbol is nullable, it
+keeps an Epsilon production, behind a new start symbol `S' -> S | EPSILON` if it
+appears on a RHS. The language is unchanged.
+*/
+func RemoveEpsilonProductions(grammar *lr1grammar.Grammar) *lr1grammar.Grammar {
+	rs := newRuleSet(grammar)
+	rs.removeEpsilonProductions()
+	return rs.result().Grammar
+}
+
+// Removes unit productions `A -> B` by giving A the other rules of every non-terminal
+// it derives through unit productions.
+func RemoveUnitProductions(grammar *lr1grammar.Grammar) *lr1grammar.Grammar {
+	rs := newRuleSet(grammar)
+	rs.removeUnitProductions()
+	return rs.result().Grammar
+}
+
+// Removes the non-terminals that derive no string of terminals with the rules using
+// them, then the symbols unreachable from the start symbol.
+func RemoveUselessSymbols(grammar *lr1grammar.Grammar) *lr1grammar.Grammar {
+	rs := newRuleSet(grammar)
+	rs.removeUselessSymbols()
+	return rs.result().Grammar
+}
+
+/*
+Converts a grammar to Chomsky Normal Form: every rule is `A -> B C` with two
+non-terminals or `A -> a` with a terminal, except `S -> EPSILON` for a nullable start
+symbol S, which then appears on no RHS. Terminals of longer rules are replaced by
+non-terminals `T_a -> a`, and longer rules are split with non-terminals `A_1`, `A_2`...
+*/
+func ToCNF(grammar *lr1grammar.Grammar) *lr1grammar.Grammar {
+	return cnfRuleSet(grammar).result().Grammar
+}
+
+/*
+Converts a grammar to Greibach Normal Form: every rule is `A -> a B1 ... Bk` with a
+terminal followed by non-terminals, except `S -> EPSILON` for a nullable start
+symbol S, which then appears on no RHS.
+
+Starts from the Chomsky Normal Form, removes left recursion without Epsilon
+productions (`A -> A α | β` becomes `A -> β | β A'` and `A' -> α | α A'`) and then
+expands leading non-terminals until every rule starts with a terminal.
+*/
+func ToGNF(grammar *lr1grammar.Grammar) *lr1grammar.Grammar {
+	rs := cnfRuleSet(grammar)
+
+	// make the rules of each non-terminal start with a terminal or a later non-terminal
+	order := slices.Clone(rs.nonTerminals)
+	for idx, nonTerminal := range order {
+		for changed := true; changed; {
+			changed = false
+			for _, earlier := range order[:idx] {
+				if (rs.substituteLeading(nonTerminal, earlier)) {
+					changed = true
+				}
+			}
+		}
+		rs.eliminateDirectLeftRecursionWithoutEpsilon(nonTerminal)
+	}
+
+	// with no left recursion left, expanding leading non-terminals terminates
+	for changed := true; changed; {
+		changed = false
+		for _, nonTerminal := range rs.nonTerminals {
+			for _, leading := range rs.nonTerminals {
+				if (rs.substituteLeading(nonTerminal, leading)) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	rs.removeDuplicateRules()
+	rs.removeUselessSymbols()
+	return rs.result().Grammar
+}
+
+func cnfRuleSet(grammar *lr1grammar.Grammar) *ruleSet {
+	rs := newRuleSet(grammar)
+
+	// the start symbol must appear on no RHS to keep an Epsilon production
+	if (rs.appearsOnRHS(rs.start)) {
+		start := rs.newNonTerminal(rs.start)
+		rs.rules[start] = []*workingRule{{start, []string{rs.start}, nil}}
+		rs.start = start
+	}
+	rs.removeEpsilonProductions()
+	rs.removeUnitProductions()
+	rs.removeUselessSymbols()
+
+	// terminals of longer rules
+	terminalNonTerminals := make(map[string]string)
+	for _, nonTerminal := range slices.Clone(rs.nonTerminals) {
+		for _, rule := range rs.rules[nonTerminal] {
+			if (len(rule.rhs) < 2) {
+				continue
+			}
+			for idx, symbol := range rule.rhs {
+				if (rs.isNonTerminal(symbol)) {
+					continue
+				}
+				replacement, exists := terminalNonTerminals[symbol]
+				if (!exists) {
+					replacement = rs.addNonTerminal("T_" + symbol)
+					rs.rules[replacement] = []*workingRule{{replacement, []string{symbol}, nil}}
+					terminalNonTerminals[symbol] = replacement
+				}
+				rule.rhs[idx] = replacement
+			}
+		}
+	}
+
+	// longer rules, A -> X1 A_1, A_1 -> X2 A_2, ..., sharing the non-terminals of equal tails
+	tails := make(map[string]string)
+	for _, nonTerminal := range slices.Clone(rs.nonTerminals) {
+		parts := 0
+		for _, rule := range rs.rules[nonTerminal] {
+			current := rule
+			for len(current.rhs) > 2 {
+				key := strings.Join(current.rhs[1:], "\x00")
+				tail, exists := tails[key]
+				if (!exists) {
+					parts++
+					tail = rs.addNonTerminal(fmt.Sprintf("%s_%d", nonTerminal, parts))
+					rs.rules[tail] = []*workingRule{{tail, current.rhs[1:], nil}}
+					tails[key] = tail
+				}
+				next := rs.rules[tail][0]
+				current.rhs = []string{current.rhs[0], tail}
+				if (exists) {
+					break
+				}
+				current = next
+			}
+		}
+	}
+	return rs
+}
+
+func (rs *ruleSet) appearsOnRHS(symbol string) bool {
+	for _, nonTerminal := range rs.nonTerminals {
+		for _, rule := range rs.rules[nonTerminal] {
+			if (slices.Contains(rule.rhs, symbol)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (rs *ruleSet) nullable() map[string]bool {
+	nullable := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, nonTerminal := range rs.nonTerminals {
+			if (nullable[nonTerminal]) {
+				continue
+			}
+			for _, rule := range rs.rules[nonTerminal] {
+				if (!slices.ContainsFunc(rule.rhs, func (symbol string) bool { return !nullable[symbol] })) {
+					nullable[nonTerminal] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return nullable
+}
+
+func (rs *ruleSet) removeEpsilonProductions() {
+	nullable := rs.nullable()
+	for _, nonTerminal := range rs.nonTerminals {
+		rules := []*workingRule{}
+		for _, rule := range rs.rules[nonTerminal] {
+			for _, rhs := range withoutNullable(rule.rhs, nullable) {
+				if (len(rhs) > 0) {
+					rules = append(rules, &workingRule{nonTerminal, rhs, nil})
+				}
+			}
+		}
+		rs.rules[nonTerminal] = rules
+	}
+	rs.removeDuplicateRules()
+
+	if (!nullable[rs.start]) {
+		return
+	}
+	if (rs.appearsOnRHS(rs.start)) {
+		start := rs.newNonTerminal(rs.start)
+		rs.rules[start] = []*workingRule{{start, []string{rs.start}, nil}}
+		rs.start = start
+	}
+	rs.rules[rs.start] = append(rs.rules[rs.start], &workingRule{rs.start, []string{}, nil})
+}
+
+// Every variant of a RHS without some of its nullable symbols, the whole RHS first.
+func withoutNullable(rhs []string, nullable map[string]bool) [][]string {
+	if (len(rhs) == 0) {
+		return [][]string{{}}
+	}
+	variants := [][]string{}
+	for _, rest := range withoutNullable(rhs[1:], nullable) {
+		variants = append(variants, append([]string{rhs[0]}, rest...))
+	}
+	if (nullable[rhs[0]]) {
+		variants = append(variants, withoutNullable(rhs[1:], nullable)...)
+	}
+	return variants
+}
+
+func (rs *ruleSet) isUnit(rule *workingRule) bool {
+	return len(rule.rhs) == 1 && rs.isNonTerminal(rule.rhs[0])
+}
+
+func (rs *ruleSet) removeUnitProductions() {
+	rules := make(map[string][]*workingRule)
+	for _, nonTerminal := range rs.nonTerminals {
+		// non-terminals derived through unit productions, in the order they are found
+		derived := []string{nonTerminal}
+		for idx := 0; idx < len(derived); idx++ {
+			for _, rule := range rs.rules[derived[idx]] {
+				if (rs.isUnit(rule) && !slices.Contains(derived, rule.rhs[0])) {
+					derived = append(derived, rule.rhs[0])
+				}
+			}
+		}
+
+		for _, other := range derived {
+			for _, rule := range rs.rules[other] {
+				if (!rs.isUnit(rule)) {
+					rules[nonTerminal] = append(rules[nonTerminal], &workingRule{nonTerminal, rule.rhs, rule.plan})
+				}
+			}
+		}
+	}
+	for _, nonTerminal := range rs.nonTerminals {
+		rs.rules[nonTerminal] = rules[nonTerminal]
+	}
+	rs.removeDuplicateRules()
+}
+
+func (rs *ruleSet) removeUselessSymbols() {
+	generating := make(map[string]bool)
+	generates := func (rule *workingRule) bool {
+		return !slices.ContainsFunc(rule.rhs, func (symbol string) bool {
+			return rs.isNonTerminal(symbol) && !generating[symbol]
+		})
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, nonTerminal := range rs.nonTerminals {
+			if (!generating[nonTerminal] && slices.ContainsFunc(rs.rules[nonTerminal], generates)) {
+				generating[nonTerminal] = true
+				changed = true
+			}
+		}
+	}
+	for _, nonTerminal := range rs.nonTerminals {
+		rs.rules[nonTerminal] = slices.DeleteFunc(rs.rules[nonTerminal], func (rule *workingRule) bool {
+			return !generates(rule)
+		})
+	}
+
+	// the start symbol stays, without rules if the language is empty
+	reachable := rs.reachable()
+	used := make(map[string]bool)
+	nonTerminals := []string{}
+	for _, nonTerminal := range rs.nonTerminals {
+		if (!reachable[nonTerminal] || (!generating[nonTerminal] && nonTerminal != rs.start)) {
+			delete(rs.rules, nonTerminal)
+			continue
+		}
+		nonTerminals = append(nonTerminals, nonTerminal)
+		for _, rule := range rs.rules[nonTerminal] {
+			for _, symbol := range rule.rhs {
+				used[symbol] = true
+			}
+		}
+	}
+	rs.nonTerminals = nonTerminals
+	rs.terminals = slices.DeleteFunc(rs.terminals, func (terminal string) bool { return !used[terminal] })
+}
+
+func (rs *ruleSet) removeDuplicateRules() {
+	for _, nonTerminal := range rs.nonTerminals {
+		seen := make(map[string]bool)
+		rs.rules[nonTerminal] = slices.DeleteFunc(rs.rules[nonTerminal], func (rule *workingRule) bool {
+			key := strings.Join(rule.rhs, "\x00")
+			duplicate := seen[key]
+			seen[key] = true
+			return duplicate
+		})
+	}
+}
+
+func (rs *ruleSet) eliminateDirectLeftRecursionWithoutEpsilon(nonTerminal string) {
+	recursive := []*workingRule{}
+	others := []*workingRule{}
+	for _, rule := range rs.rules[nonTerminal] {
+		if (len(rule.rhs) > 0 && rule.rhs[0] == nonTerminal) {
+			if (len(rule.rhs) > 1) {
+				recursive = append(recursive, rule)
+			}
+		} else {
+			others = append(others, rule)
+		}
+	}
+	if (len(recursive) == 0) {
+		return
+	}
+
+	tail := rs.newNonTerminal(nonTerminal)
+	rules := []*workingRule{}
+	for _, rule := range others {
+		rules = append(rules, rule, &workingRule{nonTerminal, append(slices.Clone(rule.rhs), tail), nil})
+	}
+	for _, rule := range recursive {
+		alpha := rule.rhs[1:]
+		rs.rules[tail] = append(rs.rules[tail], &workingRule{tail, slices.Clone(alpha), nil}, &workingRule{tail, append(slices.Clone(alpha), tail), nil})
+	}
+	rs.rules[nonTerminal] = rules
+}
@@ -0,0 +1,134 @@
+package grammartransform_test
+
+import (
+	"interpreters/internal/parser/grammartransform"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/parsertest"
+	"interpreters/internal/symbols"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var arithmetic = lr1grammar.GrammarConfigJson{
+	Terminals: parsertest.SymbolTokens("+", "*", "(", ")", "num"),
+	NonTerminals: map[string][]lr1grammar.ProductionJson{
+		"E": lr1grammar.NewProductionsJson([]string{"E", "+", "T"}, []string{"T"}),
+		"T": lr1grammar.NewProductionsJson([]string{"T", "*", "F"}, []string{"F"}),
+		"F": lr1grammar.NewProductionsJson([]string{"(", "E", ")"}, []string{"num"}),
+	},
+	StartSymbol: "E",
+}
+
+var nullable = lr1grammar.GrammarConfigJson{
+	Terminals: parsertest.SymbolTokens("a", "b"),
+	NonTerminals: map[string][]lr1grammar.ProductionJson{
+		"S": lr1grammar.NewProductionsJson([]string{"A", "B"}),
+		"A": lr1grammar.NewProductionsJson([]string{"a", "A"}, []string{symbols.Epsilon}),
+		"B": lr1grammar.NewProductionsJson([]string{"b"}, []string{symbols.Epsilon}),
+	},
+	StartSymbol: "S",
+}
+
+func TestRemoveEpsilonProductions(t *testing.T) {
+	grammar := grammartransform.RemoveEpsilonProductions(lr1grammar.MustNewGrammar(nullable))
+	expected := []string{"A -> a A", "A -> a", "B -> b", "S -> A B", "S -> A", "S -> B", "S -> EPSILON"}
+	if diff := deep.Equal(productions(grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestRemoveEpsilonProductionsAddsStartSymbol(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: parsertest.SymbolTokens("(", ")"),
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"(", "S", ")", "S"}, []string{symbols.Epsilon}),
+		},
+		StartSymbol: "S",
+	}
+	grammar := grammartransform.RemoveEpsilonProductions(lr1grammar.MustNewGrammar(config))
+	expected := []string{"S -> ( S ) S", "S -> ( S )", "S -> ( ) S", "S -> ( )", "S' -> S", "S' -> EPSILON"}
+	if diff := deep.Equal(productions(grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+	if (grammar.StartSymbol != "S'") {
+		t.Errorf("unexpected start symbol %s", grammar.StartSymbol)
+	}
+}
+
+func TestRemoveUnitProductions(t *testing.T) {
+	grammar := grammartransform.RemoveUnitProductions(lr1grammar.MustNewGrammar(arithmetic))
+	expected := []string{
+		"E -> E + T", "E -> T * F", "E -> ( E )", "E -> num",
+		"F -> ( E )", "F -> num",
+		"T -> T * F", "T -> ( E )", "T -> num",
+	}
+	if diff := deep.Equal(productions(grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestRemoveUselessSymbols(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: parsertest.SymbolTokens("a", "b", "c"),
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			// A derives no string of terminals, so B is only reachable through a useless rule
+			"S": lr1grammar.NewProductionsJson([]string{"a"}, []string{"A", "B"}),
+			"A": lr1grammar.NewProductionsJson([]string{"A", "b"}),
+			"B": lr1grammar.NewProductionsJson([]string{"b"}),
+			"C": lr1grammar.NewProductionsJson([]string{"c"}),
+		},
+		StartSymbol: "S",
+	}
+	grammar := grammartransform.RemoveUselessSymbols(lr1grammar.MustNewGrammar(config))
+	if diff := deep.Equal(productions(grammar), []string{"S -> a"}); diff != nil {
+		t.Error(diff)
+	}
+	for _, symbol := range []string{"A", "B", "C", "b", "c"} {
+		if _, exists := grammar.Symbols.Id(symbol); exists {
+			t.Errorf("%s was kept", symbol)
+		}
+	}
+}
+
+func TestToCNF(t *testing.T) {
+	grammar := grammartransform.ToCNF(lr1grammar.MustNewGrammar(arithmetic))
+	expected := []string{
+		"E -> E E_1", "E -> T E_2", "E -> T_( E_3", "E -> num",
+		"E' -> E E_1", "E' -> T E_2", "E' -> T_( E_3", "E' -> num",
+		"E_1 -> T_+ T", "E_2 -> T_* F", "E_3 -> E T_)",
+		"F -> T_( E_3", "F -> num",
+		"T -> T E_2", "T -> T_( E_3", "T -> num",
+		"T_( -> (", "T_) -> )", "T_* -> *", "T_+ -> +",
+	}
+	if diff := deep.Equal(productions(grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+
+	grammar = grammartransform.ToCNF(lr1grammar.MustNewGrammar(nullable))
+	expected = []string{"A -> T_a A", "A -> a", "B -> b", "S -> A B", "S -> EPSILON", "S -> T_a A", "S -> a", "S -> b", "T_a -> a"}
+	if diff := deep.Equal(productions(grammar), expected); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestToGNF(t *testing.T) {
+	for _, config := range []lr1grammar.GrammarConfigJson{arithmetic, nullable} {
+		grammar := grammartransform.ToGNF(lr1grammar.MustNewGrammar(config))
+		for ruleId := 0; ruleId < len(grammar.ProductionRules); ruleId++ {
+			rhs := grammar.RuleRHS(ruleId)
+			if (len(rhs) == 0 && grammar.Symbols.Name(grammar.RuleLHS(ruleId)) == grammar.StartSymbol) {
+				continue
+			}
+			valid := len(rhs) > 0 && grammar.Symbols.IsTerminal(rhs[0])
+			for _, symbol := range rhs[min(len(rhs), 1):] {
+				if (grammar.Symbols.IsTerminal(symbol)) {
+					valid = false
+				}
+			}
+			if (!valid) {
+				t.Errorf("%s: not in Greibach Normal Form", productions(grammar)[ruleId])
+			}
+		}
+	}
+}
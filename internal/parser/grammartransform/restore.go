@@ -0,0 +1,152 @@
+package grammartransform
+
+import (
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/parser/lr1parser"
+	"slices"
+)
+
+/*
+How to rebuild the original tree from a node of a transformed rule. The frontier of a
+plan lines up with the children of the node, preceded by the children its parent
+passed down to it: a nil item takes one child as is, and a nested plan builds one
+element out of as many children as its width.
+
+With those elements a plan then builds a node of an original rule, passes them down
+to its last child (a non-terminal introduced by the transformation, which finishes
+the node), or stands for its only element.
+*/
+type plan struct {
+	// original rule of the node built, -1 if none
+	rule int
+	items []*plan
+	pass bool
+}
+
+// Plan of a rule left as it was.
+func originalPlan(ruleId int, length int) *plan {
+	return &plan{ruleId, make([]*plan, length), false}
+}
+
+// Number of children the plan takes, including the child it passes its elements to.
+func (p *plan) width() int {
+	width := 0
+	for _, item := range p.items {
+		if (item == nil) {
+			width++
+		} else {
+			width += item.width()
+		}
+	}
+	if (p.pass) {
+		width++
+	}
+	return width
+}
+
+// A copy of the plan where `other` builds the element of the first frontier child.
+// Rules without a plan, whose trees cannot be restored, stay without one.
+func (p *plan) substituteFirst(other *plan) *plan {
+	if (p == nil || other == nil) {
+		return nil
+	}
+	items := slices.Clone(p.items)
+	for idx, item := range items {
+		if (item == nil) {
+			items[idx] = other
+			return &plan{p.rule, items, p.pass}
+		}
+		if (item.width() > 0) {
+			items[idx] = item.substituteFirst(other)
+			return &plan{p.rule, items, p.pass}
+		}
+	}
+	panic("plan has no frontier child to substitute")
+}
+
+// An element passed down after being rebuilt into a value of the original tree.
+type restored struct {
+	value any
+}
+
+/*
+Rewrites a tree of the transformed grammar into the tree of the same input under the
+original grammar, shaped by the annotations of the original rules. Trees of a chain
+of transformations are restored by each `Result` in reverse order.
+*/
+func (r *Result) RestoreTree(tree *cst.Node) (*cst.Node, error) {
+	value, err := r.restoreNode(tree, nil)
+	if err != nil {
+		return nil, err
+	}
+	return lr1parser.TreeBuilder{}.Root(value), nil
+}
+
+func (r *Result) restoreNode(node *cst.Node, passed []any) (any, error) {
+	if (node.RuleId < 0 || node.RuleId >= len(r.plans)) {
+		return nil, fmt.Errorf(`Tree does not match the transformed grammar: no rule %d`, node.RuleId)
+	}
+
+	children := slices.Clone(passed)
+	for _, child := range node.Children {
+		children = append(children, child)
+	}
+	return r.apply(r.plans[node.RuleId], children)
+}
+
+func (r *Result) apply(p *plan, children []any) (any, error) {
+	if (p.width() != len(children)) {
+		return nil, fmt.Errorf(`Tree does not match the transformed grammar: expected %d children, got %d`, p.width(), len(children))
+	}
+
+	elements := []any{}
+	position := 0
+	for _, item := range p.items {
+		if (item == nil) {
+			elements = append(elements, children[position])
+			position++
+			continue
+		}
+		width := item.width()
+		value, err := r.apply(item, children[position:position + width])
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, restored{value})
+		position += width
+	}
+
+	if (p.pass) {
+		next, isNode := children[position].(*cst.Node)
+		if (!isNode) {
+			return nil, fmt.Errorf(`Tree does not match the transformed grammar: expected a node to pass children to`)
+		}
+		return r.restoreNode(next, elements)
+	}
+	if (p.rule < 0) {
+		return r.value(elements[0])
+	}
+
+	values := make([]any, len(elements))
+	for idx, element := range elements {
+		value, err := r.value(element)
+		if err != nil {
+			return nil, err
+		}
+		values[idx] = value
+	}
+	return lr1parser.TreeBuilder{}.Reduce(p.rule, r.Original.ProductionRules[uint(p.rule)], values)
+}
+
+func (r *Result) value(element any) (any, error) {
+	switch element := element.(type) {
+	case restored:
+		return element.value, nil
+	case *cst.Leaf:
+		return lr1parser.TreeBuilder{}.Shift(element.Token), nil
+	case *cst.Node:
+		return r.restoreNode(element, nil)
+	}
+	return nil, fmt.Errorf(`Tree does not match the transformed grammar: unexpected element %T`, element)
+}
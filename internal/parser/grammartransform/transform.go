@@ -0,0 +1,179 @@
+package grammartransform
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
+	"slices"
+)
+
+/*
+A grammar rewritten by a transformation, with what it takes to map its trees back
+onto the original grammar. The rules of the transformed grammar have no annotations:
+trees built with it are unshaped, and `RestoreTree` shapes them with the annotations
+of the original rules.
+*/
+type Result struct {
+	Grammar *lr1grammar.Grammar
+	// original non-terminal of each non-terminal introduced by the transformation
+	Origins map[string]string
+	Original *lr1grammar.Grammar
+	// restore plan of each rule of `Grammar`, by rule id
+	plans []*plan
+}
+
+/*
+The config of the transformed grammar with the given token definitions, typically
+the terminals of the config the original grammar was built from. Grammars and
+parsers built from it number their rules like `Grammar`.
+*/
+func (r *Result) Config(terminals lexer.LexerConfigJson) lr1grammar.GrammarConfigJson {
+	nonTerminals := make(map[string][]lr1grammar.ProductionJson)
+	for ruleId := 0; ruleId < len(r.Grammar.ProductionRules); ruleId++ {
+		rule := r.Grammar.ProductionRules[uint(ruleId)]
+		nonTerminals[rule.NonTerminal] = append(nonTerminals[rule.NonTerminal], lr1grammar.NewProductionJson(rule.Production...))
+	}
+
+	displayNames := make(map[string]string)
+	for terminal, displayName := range r.Grammar.DisplayNames {
+		displayNames[terminal] = displayName
+	}
+	return lr1grammar.GrammarConfigJson{
+		Terminals: terminals,
+		NonTerminals: nonTerminals,
+		StartSymbol: r.Grammar.StartSymbol,
+		DisplayNames: displayNames,
+	}
+}
+
+// A production rule being transformed. The RHS is empty for Epsilon productions.
+type workingRule struct {
+	lhs string
+	rhs []string
+	plan *plan
+}
+
+// The rules of a grammar being transformed, by non-terminal.
+type ruleSet struct {
+	original *lr1grammar.Grammar
+	start string
+	terminals []string
+	// original non-terminals in symbol order, then the introduced ones
+	nonTerminals []string
+	rules map[string][]*workingRule
+	origins map[string]string
+}
+
+func newRuleSet(grammar *lr1grammar.Grammar) *ruleSet {
+	rs := &ruleSet{grammar, grammar.StartSymbol, []string{}, []string{}, make(map[string][]*workingRule), make(map[string]string)}
+	for id := 0; id < grammar.Symbols.Len(); id++ {
+		name := grammar.Symbols.Name(id)
+		if (!grammar.Symbols.IsTerminal(id)) {
+			rs.nonTerminals = append(rs.nonTerminals, name)
+			rs.rules[name] = []*workingRule{}
+		} else if (name != symbols.EOF && name != symbols.Error) {
+			rs.terminals = append(rs.terminals, name)
+		}
+	}
+	for ruleId := 0; ruleId < len(grammar.ProductionRules); ruleId++ {
+		rule := grammar.ProductionRules[uint(ruleId)]
+		rhs := slices.Clone(rule.Production[:rule.Length()])
+		rs.rules[rule.NonTerminal] = append(rs.rules[rule.NonTerminal], &workingRule{rule.NonTerminal, rhs, originalPlan(ruleId, len(rhs))})
+	}
+	return rs
+}
+
+// Introduces a non-terminal standing for part of `nonTerminal`, named after it with
+// as many primes as it takes to be unique.
+func (rs *ruleSet) newNonTerminal(nonTerminal string) string {
+	name := rs.addNonTerminal(nonTerminal + "'")
+	origin, introduced := rs.origins[nonTerminal]
+	if (!introduced) {
+		origin = nonTerminal
+	}
+	rs.origins[name] = origin
+	return name
+}
+
+// Adds a non-terminal without rules, named `name` with as many primes as it takes to
+// be unique.
+func (rs *ruleSet) addNonTerminal(name string) string {
+	for {
+		_, taken := rs.rules[name]
+		if (!taken && !rs.original.AllSymbols.Has(name)) {
+			break
+		}
+		name += "'"
+	}
+	rs.nonTerminals = append(rs.nonTerminals, name)
+	rs.rules[name] = []*workingRule{}
+	return name
+}
+
+func (rs *ruleSet) isNonTerminal(symbol string) bool {
+	_, exists := rs.rules[symbol]
+	return exists
+}
+
+// Non-terminals reachable from the start symbol.
+func (rs *ruleSet) reachable() map[string]bool {
+	reachable := map[string]bool{rs.start: true}
+	pending := []string{rs.start}
+	for len(pending) > 0 {
+		current := pending[len(pending) - 1]
+		pending = pending[:len(pending) - 1]
+		for _, rule := range rs.rules[current] {
+			for _, symbol := range rule.rhs {
+				if (rs.isNonTerminal(symbol) && !reachable[symbol]) {
+					reachable[symbol] = true
+					pending = append(pending, symbol)
+				}
+			}
+		}
+	}
+	return reachable
+}
+
+// Drops the non-terminals that were reachable before but no longer are.
+func (rs *ruleSet) dropUnreachable(before map[string]bool) {
+	after := rs.reachable()
+	nonTerminals := []string{}
+	for _, nonTerminal := range rs.nonTerminals {
+		if (before[nonTerminal] && !after[nonTerminal]) {
+			delete(rs.rules, nonTerminal)
+		} else {
+			nonTerminals = append(nonTerminals, nonTerminal)
+		}
+	}
+	rs.nonTerminals = nonTerminals
+}
+
+// Builds the transformed grammar, enumerating its rules like `lr1grammar.NewGrammar`.
+func (rs *ruleSet) result() *Result {
+	nonTerminals := slices.Clone(rs.nonTerminals)
+	lr1grammar.SortNonTerminals(nonTerminals)
+
+	rules := []lr1grammar.ProductionRule{}
+	plans := []*plan{}
+	for _, nonTerminal := range nonTerminals {
+		for _, rule := range rs.rules[nonTerminal] {
+			production := rule.rhs
+			if (len(production) == 0) {
+				production = []string{symbols.Epsilon}
+			}
+			rules = append(rules, lr1grammar.ProductionRule{NonTerminal: nonTerminal, Production: production})
+			plans = append(plans, rule.plan)
+		}
+	}
+
+	grammar, err := lr1grammar.NewGrammarFromRules(rs.terminals, nonTerminals, rs.start, rules)
+	if err != nil {
+		// unreachable: `newRuleSet` leaves the reserved symbols out of the terminals
+		panic(err.Error())
+	}
+	for terminal, displayName := range rs.original.DisplayNames {
+		grammar.DisplayNames[terminal] = displayName
+	}
+
+	return &Result{grammar, rs.origins, rs.original, plans}
+}
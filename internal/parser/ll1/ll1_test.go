@@ -0,0 +1,150 @@
+package ll1_test
+
+import (
+	"errors"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/ll1"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/parsertest"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func arithmeticConfig(nonTerminals map[string][]lr1grammar.ProductionJson) lr1grammar.GrammarConfigJson {
+	return lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "+", Pattern: `(\+)`},
+				{Type: "*", Pattern: `(\*)`},
+				{Type: "(", Pattern: `(\()`},
+				{Type: ")", Pattern: `(\))`},
+			},
+			GenericTokens: lexer.TokenConfigJsonArr{
+				{Type: "num", Pattern: `(\d+)`},
+			},
+		},
+		NonTerminals: nonTerminals,
+		StartSymbol: "E",
+	}
+}
+
+func TestPredictiveTableReportsConflicts(t *testing.T) {
+	config := arithmeticConfig(map[string][]lr1grammar.ProductionJson{
+		"E": lr1grammar.NewProductionsJson([]string{"E", "+", "T"}, []string{"T"}),
+		"T": lr1grammar.NewProductionsJson([]string{"SIGN", "num"}, []string{"SIGN", "(", "E", ")"}, []string{"*", "T"}),
+		"SIGN": lr1grammar.NewProductionsJson([]string{"*"}, []string{"EPSILON"}),
+	})
+	table := ll1.NewPredictiveTable(lr1grammar.MustNewGrammar(config))
+
+	conflicts := []string{}
+	for _, conflict := range table.Conflicts {
+		conflicts = append(conflicts, conflict.String())
+	}
+	expected := []string{
+		"FIRST/FIRST conflict for E on '(': E -> E + T, E -> T",
+		"FIRST/FIRST conflict for E on '*': E -> E + T, E -> T",
+		"FIRST/FIRST conflict for E on num: E -> E + T, E -> T",
+		"FIRST/FIRST conflict for T on '*': T -> SIGN num, T -> SIGN ( E ), T -> * T",
+	}
+	if diff := deep.Equal(conflicts, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	// an optional list item before a list of the same items
+	config = lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "a", Pattern: "(a)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"OPT", "LIST"}),
+			"OPT": lr1grammar.NewProductionsJson([]string{"a"}, []string{"EPSILON"}),
+			"LIST": lr1grammar.NewProductionsJson([]string{"a", "LIST"}, []string{"EPSILON"}),
+		},
+		StartSymbol: "S",
+	}
+	_, err := ll1.NewParser(config)
+	if (err == nil || err.Error() != "Grammar is not LL(1): FIRST/FOLLOW conflict for OPT on a: OPT -> a, OPT -> EPSILON (FOLLOW)") {
+		t.Errorf("expected a FIRST/FOLLOW conflict, got: %v", err)
+	}
+}
+
+func TestParser(t *testing.T) {
+	config := arithmeticConfig(map[string][]lr1grammar.ProductionJson{
+		"E": lr1grammar.NewProductionsJson([]string{"T", "E'"}),
+		"E'": lr1grammar.NewProductionsJson([]string{"+", "T", "E'"}, []string{"EPSILON"}),
+		"T": lr1grammar.NewProductionsJson([]string{"F", "T'"}),
+		"T'": lr1grammar.NewProductionsJson([]string{"*", "F", "T'"}, []string{"EPSILON"}),
+		"F": lr1grammar.NewProductionsJson([]string{"num"}, []string{"(", "E", ")"}),
+	})
+	parser, err := ll1.NewParser(config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	tree, err := parser.ParseString("1 + 2 * (3)")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := "(E (T (F 1) (T')) (E' + (T (F 2) (T' * (F ( (E (T (F 3) (T')) (E')) )) (T'))) (E')))"
+	if (parsertest.Shape(tree) != expected) {
+		t.Errorf("expected %s, got %s", expected, parsertest.Shape(tree))
+	}
+
+	for input, expected := range map[string]string{
+		"1 +": "unexpected end of input at 1:4, expected '(' or num",
+		// FOLLOW(T') is expected: it is not known that the parenthesis is still open
+		"(1 2": "unexpected '2' at 1:4, expected ')', '*', '+' or end of input",
+		"1 )": "unexpected ')' at 1:3, expected end of input",
+	} {
+		_, err := parser.ParseString(input)
+		var syntaxError *lr1parser.SyntaxError
+		if (!errors.As(err, &syntaxError)) {
+			t.Fatalf("%q: expected a syntax error, got: %v", input, err)
+		}
+		if (err.Error() != expected) {
+			t.Errorf("%q: expected %q, got %q", input, expected, err.Error())
+		}
+	}
+}
+
+func TestParserMatchesLR1Trees(t *testing.T) {
+	lr1, err := lr1parser.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	parser, err := ll1.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	input := `{ "a": [1, 2, { "b": null }], "c": true }`
+	expected, err := lr1.ParseString(input)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	tree, err := parser.ParseString(input)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (parsertest.Shape(tree) != parsertest.Shape(expected)) {
+		t.Errorf("expected %s, got %s", parsertest.Shape(expected), parsertest.Shape(tree))
+	}
+}
+
+func TestParserRejectsTokensWithoutEOF(t *testing.T) {
+	parser, err := ll1.NewParserFromJsonConfig("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	tokens := *parser.Lexer.MustTokenize("[true]")
+	for _, input := range [][]*lexer.Token{{}, tokens[:len(tokens) - 1]} {
+		if _, err := parser.ParseTokens(input); !errors.Is(err, lr1parser.ErrMissingEOF) {
+			t.Errorf("expected ErrMissingEOF for %d tokens, got: %v", len(input), err)
+		}
+	}
+}
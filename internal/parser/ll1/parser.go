@@ -0,0 +1,143 @@
+package ll1
+
+import (
+	"errors"
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/symbols"
+	"io"
+	"strings"
+)
+
+/*
+A table-driven LL(1) parser: the lexer, the (non-augmented) grammar and the
+predictive table built from a single `GrammarConfigJson`. Builds the same trees as
+the LR(1) driver, up to rule ids: the LR(1) grammar also numbers the augmented start
+rule.
+*/
+type Parser struct {
+	Lexer *lexer.Lexer
+	Grammar *lr1grammar.Grammar
+	Table *PredictiveTable
+}
+
+// An error is returned if the grammar is not LL(1); the conflicts are listed in the
+// error message and `NewPredictiveTable` gives the full report.
+func NewParser(config lr1grammar.GrammarConfigJson) (*Parser, error) {
+	lex, grammar, err := lr1grammar.NewLexerAndGrammar(config, false)
+	if err != nil {
+		return nil, err
+	}
+
+	table := NewPredictiveTable(grammar)
+	if (len(table.Conflicts) > 0) {
+		messages := make([]string, len(table.Conflicts))
+		for idx, conflict := range table.Conflicts {
+			messages[idx] = conflict.String()
+		}
+		return nil, errors.New("Grammar is not LL(1): " + strings.Join(messages, "; "))
+	}
+
+	return &Parser{lex, grammar, table}, nil
+}
+
+func NewParserFromJsonConfig(path string) (*Parser, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewParser(config)
+}
+
+// Parses an input into a concrete syntax tree rooted at the start symbol.
+func (p *Parser) ParseString(input string) (*cst.Node, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ParseTokens(*tokens)
+}
+
+func (p *Parser) ParseReader(reader io.Reader) (*cst.Node, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf(`Error reading input: %w`, err)
+	}
+
+	return p.ParseString(string(bytes))
+}
+
+// Entry of the parser stack: a symbol to derive, or a rule whose RHS has been
+// derived and whose node can be built.
+type stackEntry struct {
+	symbol string
+	reduce bool
+	ruleId int
+}
+
+/*
+Parses a token stream terminated by an EOF token. Returns an `*lr1parser.SyntaxError`
+at the first token the table has no prediction for, `lr1parser.ErrMissingEOF` if the
+stream has no EOF token.
+
+Expanding a rule pushes a marker below its RHS symbols: once the RHS is derived the
+marker is popped and the node is built from the values of its children, bottom-up
+like in the LR(1) driver.
+*/
+func (p *Parser) ParseTokens(tokens []*lexer.Token) (*cst.Node, error) {
+	// the EOF token is never matched, so `position` stays within the stream
+	if err := lr1parser.CheckTokenStream(tokens); err != nil {
+		return nil, err
+	}
+
+	builder := lr1parser.TreeBuilder{}
+	stack := []stackEntry{{symbol: p.Grammar.StartSymbol}}
+	values := []any{}
+	position := 0
+
+	for len(stack) > 0 {
+		top := stack[len(stack) - 1]
+		stack = stack[:len(stack) - 1]
+
+		if (top.reduce) {
+			rule := p.Grammar.ProductionRules[uint(top.ruleId)]
+			split := len(values) - rule.Length()
+			value, err := builder.Reduce(top.ruleId, rule, values[split:])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values[:split], value)
+			continue
+		}
+
+		token := tokens[position]
+		if (!p.Grammar.NonTerminals.Has(top.symbol)) {
+			if (token.Type != top.symbol) {
+				return nil, lr1parser.NewSyntaxError(p.Grammar.DisplayName, token, []string{top.symbol})
+			}
+			values = append(values, builder.Shift(token))
+			position++
+			continue
+		}
+
+		ruleId, exists := p.Table.Rule(top.symbol, token.Type)
+		if (!exists) {
+			return nil, lr1parser.NewSyntaxError(p.Grammar.DisplayName, token, p.Table.ExpectedTerminals(top.symbol))
+		}
+		rule := p.Grammar.ProductionRules[uint(ruleId)]
+		stack = append(stack, stackEntry{reduce: true, ruleId: ruleId})
+		for idx := rule.Length() - 1; idx >= 0; idx-- {
+			stack = append(stack, stackEntry{symbol: rule.Production[idx]})
+		}
+	}
+
+	if (position != len(tokens) - 1) {
+		return nil, lr1parser.NewSyntaxError(p.Grammar.DisplayName, tokens[position], []string{symbols.EOF})
+	}
+	return builder.Root(values[0]), nil
+}
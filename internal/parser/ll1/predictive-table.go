@@ -0,0 +1,170 @@
+package ll1
+
+import (
+	"fmt"
+	"interpreters/internal/parser/firstfollow"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
+	"interpreters/utilities/sets"
+	"slices"
+	"strings"
+)
+
+/*
+Predictive parsing table of a non-augmented `Grammar`: for a non-terminal on top of
+the stack and a lookahead terminal, the production rule to expand. A rule for `A -> α`
+is predicted on FIRST(α), and on FOLLOW(A) if α derives Epsilon. Cells predicting
+more than one rule are listed in `Conflicts`.
+*/
+type PredictiveTable struct {
+	Grammar *lr1grammar.Grammar
+	FIRST map[string]sets.Set[string]
+	FOLLOW map[string]sets.Set[string]
+	Conflicts []Conflict
+	// rule ids by non-terminal and terminal, in rule id order
+	table map[string]map[string][]int
+}
+
+// Two or more production rules predicted by the same table cell.
+type Conflict struct {
+	NonTerminal string
+	Terminal string
+	Rules []int
+	// rules predicted because they derive Epsilon and the terminal is in FOLLOW
+	followRules []int
+	grammar *lr1grammar.Grammar
+}
+
+// "FIRST/FIRST" if the terminal starts every rule involved, "FIRST/FOLLOW" if it
+// follows a rule that derives Epsilon.
+func (c Conflict) Kind() string {
+	if (len(c.followRules) > 0) {
+		return "FIRST/FOLLOW"
+	}
+	return "FIRST/FIRST"
+}
+
+func (c Conflict) String() string {
+	rules := make([]string, len(c.Rules))
+	for idx, ruleId := range c.Rules {
+		rule := c.grammar.ProductionRules[uint(ruleId)]
+		rules[idx] = rule.NonTerminal + " -> " + strings.Join(rule.Production, " ")
+		if (slices.Contains(c.followRules, ruleId)) {
+			rules[idx] += " (FOLLOW)"
+		}
+	}
+	return fmt.Sprintf(
+		"%s conflict for %s on %s: %s",
+		c.Kind(),
+		c.NonTerminal,
+		c.grammar.DisplayName(c.Terminal),
+		strings.Join(rules, ", "),
+	)
+}
+
+func NewPredictiveTable(grammar *lr1grammar.Grammar) *PredictiveTable {
+	FIRSTSets, FOLLOWSets := firstfollow.ComputeFIRSTandFOLLOW(grammar)
+	pt := &PredictiveTable{grammar, FIRSTSets, FOLLOWSets, []Conflict{}, make(map[string]map[string][]int)}
+
+	// terminals predicting a rule through FOLLOW, by rule
+	predictedByFOLLOW := make(map[int]sets.Set[string])
+	for ruleId := 0; ruleId < len(grammar.ProductionRules); ruleId++ {
+		rule := grammar.ProductionRules[uint(ruleId)]
+		row, exists := pt.table[rule.NonTerminal]
+		if (!exists) {
+			row = make(map[string][]int)
+			pt.table[rule.NonTerminal] = row
+		}
+
+		first, nullable := pt.FIRSTOfProduction(rule.Production)
+		predicted := first.GetItems()
+		if (nullable) {
+			byFOLLOW := sets.NewEmptySet[string]()
+			follow := FOLLOWSets[rule.NonTerminal]
+			for _, terminal := range follow.GetItems() {
+				if (!first.Has(terminal)) {
+					byFOLLOW.Add(terminal)
+					predicted = append(predicted, terminal)
+				}
+			}
+			predictedByFOLLOW[ruleId] = byFOLLOW
+		}
+		for _, terminal := range predicted {
+			row[terminal] = append(row[terminal], ruleId)
+		}
+	}
+
+	for _, nonTerminal := range sortedKeys(pt.table) {
+		row := pt.table[nonTerminal]
+		for _, terminal := range pt.terminals() {
+			rules := row[terminal]
+			if (len(rules) < 2) {
+				continue
+			}
+			followRules := []int{}
+			for _, ruleId := range rules {
+				if byFOLLOW, exists := predictedByFOLLOW[ruleId]; exists && byFOLLOW.Has(terminal) {
+					followRules = append(followRules, ruleId)
+				}
+			}
+			pt.Conflicts = append(pt.Conflicts, Conflict{nonTerminal, terminal, rules, followRules, grammar})
+		}
+	}
+
+	return pt
+}
+
+// FIRST of a sequence of symbols and whether the whole sequence derives Epsilon.
+func (pt *PredictiveTable) FIRSTOfProduction(production []string) (sets.Set[string], bool) {
+	first := sets.NewEmptySet[string]()
+	for _, symbol := range production {
+		if (symbol == symbols.Epsilon) {
+			continue
+		}
+		first = first.Union(pt.FIRST[symbol])
+		first.Delete(symbols.Epsilon)
+		if (!pt.Grammar.DerivesEpsilon(symbol)) {
+			return first, false
+		}
+	}
+	return first, true
+}
+
+// Get the rule to expand a non-terminal with on a lookahead terminal. The first rule
+// is returned for conflicting cells; false for empty ones.
+func (pt *PredictiveTable) Rule(nonTerminal string, terminal string) (int, bool) {
+	rules := pt.table[nonTerminal][terminal]
+	if (len(rules) == 0) {
+		return -1, false
+	}
+	return rules[0], true
+}
+
+// Get the terminals predicting a rule for a non-terminal, in symbol order.
+func (pt *PredictiveTable) ExpectedTerminals(nonTerminal string) []string {
+	expected := []string{}
+	for _, terminal := range pt.terminals() {
+		if (terminal != symbols.Error && len(pt.table[nonTerminal][terminal]) > 0) {
+			expected = append(expected, terminal)
+		}
+	}
+	return expected
+}
+
+// Terminals in symbol order, EOF last.
+func (pt *PredictiveTable) terminals() []string {
+	terminals := make([]string, pt.Grammar.Symbols.NumTerminals())
+	for id := range terminals {
+		terminals[id] = pt.Grammar.Symbols.Name(id)
+	}
+	return terminals
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,172 @@
+package ll1codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"interpreters/internal/parser/ll1"
+	"interpreters/internal/parser/lr1codegen"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type Options struct {
+	// name of the generated package
+	PackageName string
+}
+
+// A parse function: one per non-terminal.
+type functionData struct {
+	NonTerminal string
+	Name string
+	Alternatives []alternativeData
+	// terminals predicting any alternative
+	Expected []string
+}
+
+type alternativeData struct {
+	RuleId int
+	Rule string
+	// terminals predicting the alternative, from FIRST and FOLLOW
+	Terminals []string
+	Steps []stepData
+}
+
+// Parses one RHS symbol: a terminal to match or the function of a non-terminal.
+type stepData struct {
+	Terminal string
+	Function string
+}
+
+type templateData struct {
+	PackageName string
+	lr1codegen.LexerData
+	Start string
+	Functions []functionData
+}
+
+/*
+Generates a self-contained recursive-descent parser for the language of an LL(1)
+`GrammarConfigJson`: one Go function per non-terminal, switching on the lookahead
+token to pick the production rule to follow. The generated code only depends on the
+standard library and is meant to be read and, if needed, maintained by hand. Its
+`Parse` function returns an unshaped concrete syntax tree, like the parser generated
+by `lr1codegen`.
+
+Refuses to generate if the grammar has LL(1) conflicts: the error lists them. The
+output is gofmt-formatted and identical for identical configs.
+*/
+func Generate(config lr1grammar.GrammarConfigJson, options Options) ([]byte, error) {
+	if (options.PackageName == "") {
+		return nil, fmt.Errorf(`Options.PackageName is required`)
+	}
+
+	parser, err := ll1.NewParser(config)
+	if err != nil {
+		return nil, err
+	}
+	grammar := parser.Grammar
+
+	terminals := []string{}
+	nonTerminals := []string{}
+	for id := 0; id < grammar.Symbols.Len(); id++ {
+		name := grammar.Symbols.Name(id)
+		if (grammar.Symbols.IsTerminal(id)) {
+			if (name != symbols.Error) {
+				terminals = append(terminals, name)
+			}
+		} else {
+			nonTerminals = append(nonTerminals, name)
+		}
+	}
+
+	names := functionNames(nonTerminals)
+	data := templateData{
+		PackageName: options.PackageName,
+		LexerData: lr1codegen.NewLexerData(parser.Lexer, grammar, terminals),
+		Start: names[grammar.StartSymbol],
+	}
+	for _, nonTerminal := range nonTerminals {
+		function := functionData{NonTerminal: nonTerminal, Name: names[nonTerminal]}
+		function.Expected = parser.Table.ExpectedTerminals(nonTerminal)
+
+		for _, rule := range grammar.GetProductionsOfNonTerminal(nonTerminal) {
+			ruleId, _ := grammar.GetProductionId(nonTerminal, rule.Production)
+			alternative := alternativeData{
+				RuleId: ruleId,
+				Rule: nonTerminal + " -> " + strings.Join(rule.Production, " "),
+			}
+			for _, terminal := range function.Expected {
+				if predicted, _ := parser.Table.Rule(nonTerminal, terminal); predicted == ruleId {
+					alternative.Terminals = append(alternative.Terminals, terminal)
+				}
+			}
+			for _, symbol := range rule.Production[:rule.Length()] {
+				if (grammar.NonTerminals.Has(symbol)) {
+					alternative.Steps = append(alternative.Steps, stepData{Function: names[symbol]})
+				} else {
+					alternative.Steps = append(alternative.Steps, stepData{Terminal: symbol})
+				}
+			}
+			if (len(alternative.Terminals) > 0) {
+				function.Alternatives = append(function.Alternatives, alternative)
+			}
+		}
+		data.Functions = append(data.Functions, function)
+	}
+
+	var buffer bytes.Buffer
+	if err := parserTemplate.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf(`Error formatting generated parser: %w`, err)
+	}
+	return source, nil
+}
+
+func GenerateFromJsonConfig(path string, options Options) ([]byte, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Generate(config, options)
+}
+
+// Names the parse function of each non-terminal after it: "ENTRIES?" is parsed by
+// `parseENTRIESOpt` and "E'" by `parseEPrime`. Clashing names get a numeric suffix.
+func functionNames(nonTerminals []string) map[string]string {
+	names := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, nonTerminal := range nonTerminals {
+		var builder strings.Builder
+		builder.WriteString("parse")
+		for _, r := range nonTerminal {
+			switch {
+			case r == '\'':
+				builder.WriteString("Prime")
+			case r == '?':
+				builder.WriteString("Opt")
+			case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+				builder.WriteRune(r)
+			default:
+				builder.WriteRune('_')
+			}
+		}
+
+		name := builder.String()
+		for suffix := 2; taken[name]; suffix++ {
+			name = fmt.Sprintf("%s%d", builder.String(), suffix)
+		}
+		taken[name] = true
+		names[nonTerminal] = name
+	}
+	return names
+}
+
+var parserTemplate = template.Must(template.Must(template.New("parser").Funcs(lr1codegen.TemplateFuncs).Parse(lr1codegen.LexerTemplateSource)).Parse(parserTemplateSource))
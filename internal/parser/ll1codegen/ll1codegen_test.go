@@ -0,0 +1,69 @@
+package ll1codegen_test
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/codegentest"
+	"interpreters/internal/parser/ll1codegen"
+	"interpreters/internal/parser/lr1grammar"
+	"strings"
+	"testing"
+)
+
+const grammarConfigPath = "../lr1parser/grammar-config.json"
+
+func TestGenerate(t *testing.T) {
+	source := codegentest.CheckSource(t, func () ([]byte, error) {
+		return ll1codegen.GenerateFromJsonConfig(grammarConfigPath, ll1codegen.Options{PackageName: "jsonparser"})
+	})
+	if (!strings.Contains(string(source), "func (p *parser) parseENTRIESOpt() (*Node, error) {")) {
+		t.Errorf("expected a parse function named after each non-terminal")
+	}
+
+	if _, err := ll1codegen.GenerateFromJsonConfig(grammarConfigPath, ll1codegen.Options{}); (err == nil) {
+		t.Errorf("expected an error for a missing package name")
+	}
+}
+
+func TestGenerateRefusesConflicts(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "+", Pattern: `(\+)`},
+				{Type: "n", Pattern: "(n)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"E": lr1grammar.NewProductionsJson([]string{"E", "+", "n"}, []string{"n"}),
+		},
+		StartSymbol: "E",
+	}
+	_, err := ll1codegen.Generate(config, ll1codegen.Options{PackageName: "left"})
+	if (err == nil || !strings.Contains(err.Error(), "FIRST/FIRST conflict for E on n: E -> E + n, E -> n")) {
+		t.Errorf("expected the conflicts to be reported, got: %v", err)
+	}
+}
+
+// Compiles the generated package and runs it against the inputs of the lr1codegen
+// tests, plus one that the LL(1) parser reports differently.
+func TestGeneratedParser(t *testing.T) {
+	source, err := ll1codegen.GenerateFromJsonConfig(grammarConfigPath, ll1codegen.Options{PackageName: "main"})
+	if (err != nil) {
+		t.Fatal("Failed to generate parser: ", err.Error())
+	}
+
+	codegentest.RunParser(t, source, []string{
+		`true`,
+		`{ "a": [1, 2, { "b": null }], "c": false }`,
+		`[1, 2, ]`,
+		`{ "a": [`,
+		`{ "a" }`,
+		`{ @ }`,
+	}, []string{
+		"VALUE 1",
+		"VALUE 1",
+		"unexpected ']' at 1:8, expected '[', false, null, num_lit, str_lit, true or '{'",
+		"unexpected end of input at 1:9, expected '[', ']', false, null, num_lit, str_lit, true or '{'",
+		"unexpected '}' at 1:7, expected ':'",
+		"Unrecognized symbol at 1:3",
+	})
+}
@@ -0,0 +1,78 @@
+package ll1codegen
+
+// Source of the generated package: a parse function per non-terminal, see
+// `functionData`.
+const parserTemplateSource = `// Recursive-descent parser generated by ll1codegen, meant to be maintained by hand.
+
+package {{.PackageName}}
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+{{template "lexer" .}}
+
+type parser struct {
+	tokens []*Token
+	next   int
+}
+
+func (p *parser) peek() *Token {
+	return p.tokens[p.next]
+}
+
+// expect consumes the lookahead token if it is of the given type.
+func (p *parser) expect(terminal string) (*Node, error) {
+	token := p.peek()
+	if token.Type != terminal {
+		return nil, &SyntaxError{token, []string{terminal}}
+	}
+	p.next++
+	return &Node{Symbol: terminal, RuleId: -1, Token: token}, nil
+}
+
+// Parse tokenizes and parses input and returns its concrete syntax tree.
+func Parse(input string) (*Node, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens, 0}
+	node, err := p.{{.Start}}()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.Type != eof {
+		return nil, &SyntaxError{token, []string{eof}}
+	}
+	return node, nil
+}
+{{range $function := .Functions}}
+// {{.Name}} parses {{.NonTerminal}}.
+func (p *parser) {{.Name}}() (*Node, error) {
+	switch p.peek().Type {
+	{{- range .Alternatives}}
+	case {{range $idx, $terminal := .Terminals}}{{if $idx}}, {{end}}{{quote $terminal}}{{end}}:
+		// {{.Rule}}
+		{{- if .Steps}}
+		children := make([]*Node, {{len .Steps}})
+		var err error
+		{{- range $idx, $step := .Steps}}
+		if children[{{$idx}}], err = p.{{if .Function}}{{.Function}}(){{else}}expect({{quote .Terminal}}){{end}}; err != nil {
+			return nil, err
+		}
+		{{- end}}
+		return &Node{Symbol: {{quote $function.NonTerminal}}, RuleId: {{.RuleId}}, Children: children}, nil
+		{{- else}}
+		return &Node{Symbol: {{quote $function.NonTerminal}}, RuleId: {{.RuleId}}, Children: []*Node{} }, nil
+		{{- end}}
+	{{- end}}
+	}
+	return nil, &SyntaxError{p.peek(), []string{ {{range $idx, $terminal := .Expected}}{{if $idx}}, {{end}}{{quote $terminal}}{{end}} }}
+}
+{{end}}`
@@ -1,102 +1,111 @@
 package lr1closureset
 
 import (
+	"encoding/binary"
 	"interpreters/internal/parser/lr1item"
-	"interpreters/utilities/arrays"
-	"interpreters/utilities/maps"
-	"interpreters/utilities/sets"
+	"sort"
 )
 
 type LR1ClosureSet struct {
-	kernelItems map[string]*lr1item.LR1Item
-	items map[string]*lr1item.LR1Item
+	// kernel items first, then items added by `Add`, in insertion order
+	items []*lr1item.LR1Item
+	index map[lr1item.Core]int
+	numKernelItems int
 }
 
 func NewEmptyLR1ClosureSet() *LR1ClosureSet {
-	kernelItems := make(map[string]*lr1item.LR1Item)
-	items := make(map[string]*lr1item.LR1Item)
-	return &LR1ClosureSet{kernelItems, items}
+	return &LR1ClosureSet{nil, make(map[lr1item.Core]int), 0}
 }
 
 // Creates a new `LR1ClosureSet` where all initially loaded items are considered
-// `kernel` items.
+// `kernel` items. Items with the same core are merged.
 func NewLR1ClosureSet(LR1Items ...*lr1item.LR1Item) *LR1ClosureSet {
-	kernelItems := make(map[string]*lr1item.LR1Item)
-	items := make(map[string]*lr1item.LR1Item)
+	cs := NewEmptyLR1ClosureSet()
 	for _, item := range LR1Items {
-		itemName := item.GetName()
-		if _, exists := kernelItems[itemName]; !exists {
-			kernelItems[itemName] = item
-			items[itemName] = item
-		}
+		cs.Add(item)
 	}
-	return &LR1ClosureSet{kernelItems, items}
+	cs.numKernelItems = len(cs.items)
+	return cs
 }
 
-func (cs *LR1ClosureSet) Add(LR1Item *lr1item.LR1Item) {
-	itemName := LR1Item.GetName()
-	if _, exists := cs.items[itemName]; !exists {
-		cs.items[itemName] = LR1Item
+// Adds an item to the `LR1ClosureSet`. If an item with the same core already exists,
+// the lookahead sets of both items are merged. Returns whether the set changed.
+func (cs *LR1ClosureSet) Add(LR1Item *lr1item.LR1Item) bool {
+	idx, exists := cs.index[LR1Item.Core]
+	if !exists {
+		cs.index[LR1Item.Core] = len(cs.items)
+		cs.items = append(cs.items, LR1Item)
+		return true
 	}
+
+	existingItem := cs.items[idx]
+	if existingItem.Lookaheads.Contains(LR1Item.Lookaheads) {
+		return false
+	}
+
+	// items and lookahead sets may be shared between sets: replace rather than
+	// mutate the existing item
+	lookaheads := existingItem.Lookaheads.Clone()
+	lookaheads.UnionWith(LR1Item.Lookaheads)
+	cs.items[idx] = lr1item.NewLR1Item(existingItem.Rule, existingItem.Dot, lookaheads)
+	return true
 }
 
-func (cs *LR1ClosureSet) Delete(LR1Item *lr1item.LR1Item) {
-	itemName := LR1Item.GetName()
-	delete(cs.items, itemName)
+// Get the item with the given core, `nil` if there is none.
+func (cs *LR1ClosureSet) Get(core lr1item.Core) *lr1item.LR1Item {
+	idx, exists := cs.index[core]
+	if !exists {
+		return nil
+	}
+	return cs.items[idx]
 }
 
+// Get all items in insertion order, kernel items first.
 func (cs *LR1ClosureSet) GetItems() []*lr1item.LR1Item {
-	items := []*lr1item.LR1Item{}
-	for _, item := range cs.items {
-		items = append(items, item)
-	}
-	return items
+	return append([]*lr1item.LR1Item(nil), cs.items...)
 }
 
-func (cs *LR1ClosureSet) Clone() *LR1ClosureSet {
-	kernelCopy := maps.DeepCopy(cs.kernelItems)
-	itemsCopy := maps.DeepCopy(cs.items)
-	return &LR1ClosureSet{kernelCopy, itemsCopy}
+// Get the kernel items of the `LR1ClosureSet`, ordered by core.
+func (cs *LR1ClosureSet) GetKernelItems() []*lr1item.LR1Item {
+	items := append([]*lr1item.LR1Item(nil), cs.items[:cs.numKernelItems]...)
+	sortItems(items)
+	return items
 }
 
-// Compares two `LR1ClosureSets` by both kernel and lookahead identity.
-func (thisSet *LR1ClosureSet) IsEqual(otherSet LR1ClosureSet) bool {
-	thisKernel := thisSet.kernelItems
-	otherKernel := otherSet.kernelItems
+// Get all items of the `LR1ClosureSet` ordered by core, kernel items first.
+func (cs *LR1ClosureSet) GetSortedItems() []*lr1item.LR1Item {
+	closureItems := append([]*lr1item.LR1Item(nil), cs.items[cs.numKernelItems:]...)
+	sortItems(closureItems)
+	return append(cs.GetKernelItems(), closureItems...)
+}
 
-	// check that both kernels are the same size
-	if len(thisKernel) != len(otherKernel) {
-		return false
-	}
+// Whether the item is one of the kernel items of the `LR1ClosureSet`.
+func (cs *LR1ClosureSet) IsKernelItem(item *lr1item.LR1Item) bool {
+	idx, exists := cs.index[item.Core]
+	return exists && idx < cs.numKernelItems
+}
 
-	// check that all items exist and lookahead sets are equal
-	for itemName, thisItem := range thisKernel {
-		otherItem, exists := otherKernel[itemName]
-		if !exists {
-			return false
-		}
-		if !thisItem.LookaheadSet.IsEqual(otherItem.LookaheadSet) {
-			return false
+// Builds a canonical key for the kernel of the `LR1ClosureSet` including
+// lookaheads. Two sets have the same kernel key if and only if their kernels hold
+// the same items with the same lookaheads.
+func (cs *LR1ClosureSet) GetKernelKey() string {
+	key := []byte{}
+	for _, item := range cs.GetKernelItems() {
+		key = binary.AppendUvarint(key, uint64(item.Rule))
+		key = binary.AppendUvarint(key, uint64(item.Dot))
+		key = binary.AppendUvarint(key, uint64(len(item.Lookaheads)))
+		for _, word := range item.Lookaheads {
+			key = binary.AppendUvarint(key, word)
 		}
 	}
-
-	return true
+	return string(key)
 }
 
-// Retrieve items whose productions are complete.
-func (cs *LR1ClosureSet) GetCompletedItems() []*lr1item.LR1Item {
-	items := cs.GetItems()
-	return arrays.Filter(items, func (item *lr1item.LR1Item) bool {
-		return item.ProductionIsComplete()
+func sortItems(items []*lr1item.LR1Item) {
+	sort.Slice(items, func (i int, j int) bool {
+		if items[i].Rule != items[j].Rule {
+			return items[i].Rule < items[j].Rule
+		}
+		return items[i].Dot < items[j].Dot
 	})
 }
-
-// Get all symbols that can follow the `dot` of every item in
-// the `LR1ClosureSet`.
-func (cs *LR1ClosureSet) GetTransitionSymbols() sets.Set[string] {
-	items := cs.GetItems()
-	transitionSymbols := arrays.Map(items, func (item *lr1item.LR1Item) string {
-		return item.GetNextSymbol()
-	})
-	return sets.NewSet(transitionSymbols...)
-}
\ No newline at end of file
@@ -0,0 +1,146 @@
+package lr1codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/lr1parsingtable"
+	"interpreters/internal/symbols"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type Options struct {
+	// name of the generated package
+	PackageName string
+}
+
+// Table in compressed sparse row form: the cells of row `r` are at indexes
+// `RowStart[r]` up to `RowStart[r+1]` of `Columns` and `Values`.
+type sparseTableData struct {
+	RowStart []int
+	Columns []int
+	Values []int
+}
+
+type templateData struct {
+	PackageName string
+	LexerData
+	NonTerminals []string
+	RuleNonTerminal []int
+	RuleLength []int
+	Action sparseTableData
+	Goto sparseTableData
+}
+
+/*
+Generates a self-contained Go package that lexes and parses the language of a
+`GrammarConfigJson`. The package embeds the token patterns and the ACTION/GOTO
+tables as static arrays and only depends on the standard library. Its `Parse`
+function returns an unshaped concrete syntax tree.
+
+The output is gofmt-formatted and identical for identical configs.
+*/
+func Generate(config lr1grammar.GrammarConfigJson, options Options) ([]byte, error) {
+	if (options.PackageName == "") {
+		return nil, fmt.Errorf(`Options.PackageName is required`)
+	}
+
+	parser, err := lr1parser.NewParser(config)
+	if err != nil {
+		return nil, err
+	}
+
+	table := parser.Table
+	data := templateData{
+		PackageName: options.PackageName,
+		LexerData: NewLexerData(parser.Lexer, parser.Grammar, table.ActionSymbols()),
+	}
+	data.NonTerminals = append(table.GotoSymbols(), symbols.AugmentedStart)
+	nonTerminalIds := make(map[string]int)
+	for idx, nonTerminal := range data.NonTerminals {
+		nonTerminalIds[nonTerminal] = idx
+	}
+
+	for ruleId := 0; ruleId < len(parser.Grammar.ProductionRules); ruleId++ {
+		rule := parser.Grammar.ProductionRules[uint(ruleId)]
+		data.RuleNonTerminal = append(data.RuleNonTerminal, nonTerminalIds[rule.NonTerminal])
+		data.RuleLength = append(data.RuleLength, rule.Length())
+	}
+
+	data.Action = sparseTable(table, data.Terminals, encodeAction)
+	data.Goto = sparseTable(table, table.GotoSymbols(), func (action lr1parsingtable.ParserAction) int {
+		return action.NextState()
+	})
+
+	var buffer bytes.Buffer
+	if err := parserTemplate.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf(`Error formatting generated parser: %w`, err)
+	}
+	return source, nil
+}
+
+func GenerateFromJsonConfig(path string, options Options) ([]byte, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Generate(config, options)
+}
+
+// Encodes an ACTION cell as in the generated package: 0 to accept, `s + 1` to shift
+// and go to state `s`, and `-(r + 1)` to reduce by rule `r`.
+func encodeAction(action lr1parsingtable.ParserAction) int {
+	switch action.ActionVerb() {
+	case lr1parsingtable.SHIFT:
+		return action.NextState() + 1
+	case lr1parsingtable.REDUCE:
+		return -(action.ReduceByRule() + 1)
+	}
+	return 0
+}
+
+func sparseTable(table *lr1parsingtable.ParsingTable, columns []string, encode func (lr1parsingtable.ParserAction) int) sparseTableData {
+	data := sparseTableData{RowStart: []int{0}}
+	for state := 0; state < table.NumStates(); state++ {
+		for column, symbol := range columns {
+			action := table.Action(state, symbol)
+			if (action.ActionVerb() == lr1parsingtable.ERROR) {
+				continue
+			}
+			data.Columns = append(data.Columns, column)
+			data.Values = append(data.Values, encode(action))
+		}
+		data.RowStart = append(data.RowStart, len(data.Columns))
+	}
+	return data
+}
+
+var parserTemplate = template.Must(template.Must(template.New("parser").Funcs(TemplateFuncs).Parse(LexerTemplateSource)).Parse(parserTemplateSource))
+
+// Functions available to the templates of the generated packages.
+var TemplateFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+	"ints": func (values []int) string {
+		var builder strings.Builder
+		for idx, value := range values {
+			if (idx > 0) {
+				builder.WriteString(", ")
+				// wrap long arrays to keep lines readable
+				if (idx % 16 == 0) {
+					builder.WriteString("\n")
+				}
+			}
+			builder.WriteString(strconv.Itoa(value))
+		}
+		return builder.String()
+	},
+}
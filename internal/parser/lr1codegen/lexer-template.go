@@ -0,0 +1,250 @@
+package lr1codegen
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+)
+
+// Data of the "lexer" template, embedded in the data of the templates using it.
+type LexerData struct {
+	SymbolTokens []tokenData
+	KeywordTokens []tokenData
+	GenericTokens []tokenData
+	IdentifierToken int
+	Terminals []string
+	TerminalDisplayNames []string
+}
+
+type tokenData struct {
+	Type string
+	Pattern string
+	ExactPattern string
+	WholeWord bool
+}
+
+// Collects the token definitions of a lexer and the terminals of a grammar, in the
+// order their IDs have in the generated package.
+func NewLexerData(lex *lexer.Lexer, grammar *lr1grammar.Grammar, terminals []string) LexerData {
+	data := LexerData{IdentifierToken: -1, Terminals: terminals}
+
+	symbolTokens, keywordTokens, genericTokens := lex.TokenGroups()
+	data.SymbolTokens = toTokenData(symbolTokens)
+	data.KeywordTokens = toTokenData(keywordTokens)
+	data.GenericTokens = toTokenData(genericTokens)
+	for idx, token := range genericTokens {
+		if (token == lex.IdentifierToken()) {
+			data.IdentifierToken = idx
+		}
+	}
+
+	for _, terminal := range terminals {
+		data.TerminalDisplayNames = append(data.TerminalDisplayNames, grammar.DisplayName(terminal))
+	}
+	return data
+}
+
+func toTokenData(tokenConfigs []*lexer.TokenConfig) []tokenData {
+	data := make([]tokenData, len(tokenConfigs))
+	for idx, tokenConfig := range tokenConfigs {
+		data[idx] = tokenData{
+			tokenConfig.Type,
+			tokenConfig.Pattern.String(),
+			tokenConfig.ExactPattern(),
+			tokenConfig.WholeWord,
+		}
+	}
+	return data
+}
+
+/*
+Source of the "lexer" template shared by the generated parsers: the `Token` and
+`Node` types, `Tokenize` and the `SyntaxError` type. The generated package must
+import fmt, regexp, strings, unicode and unicode/utf8.
+*/
+const LexerTemplateSource = `{{define "lexer"}}type Token struct {
+	Type  string
+	Value string
+	Line  uint
+	Col   uint
+}
+
+// Node of the concrete syntax tree. Leaves hold a token, inner nodes hold the
+// nonterminal and the children of its production.
+type Node struct {
+	Symbol   string
+	RuleId   int
+	Token    *Token
+	Children []*Node
+}
+
+type tokenDef struct {
+	typ       string
+	pattern   *regexp.Regexp
+	exact     *regexp.Regexp
+	wholeWord bool
+}
+
+var symbolTokens = []tokenDef{
+{{- range .SymbolTokens}}
+	{ {{quote .Type}}, regexp.MustCompile({{quote .Pattern}}), regexp.MustCompile({{quote .ExactPattern}}), {{.WholeWord}} },
+{{- end}}
+}
+
+var keywordTokens = []tokenDef{
+{{- range .KeywordTokens}}
+	{ {{quote .Type}}, regexp.MustCompile({{quote .Pattern}}), regexp.MustCompile({{quote .ExactPattern}}), {{.WholeWord}} },
+{{- end}}
+}
+
+var genericTokens = []tokenDef{
+{{- range .GenericTokens}}
+	{ {{quote .Type}}, regexp.MustCompile({{quote .Pattern}}), regexp.MustCompile({{quote .ExactPattern}}), {{.WholeWord}} },
+{{- end}}
+}
+
+// index of the identifier in genericTokens when keywords are reserved identifiers, -1 otherwise
+var identifierToken = {{.IdentifierToken}}
+
+const eof = "$"
+
+var terminals = []string{
+{{- range .Terminals}}
+	{{quote .}},
+{{- end}}
+}
+
+// names of the terminals in error messages
+var terminalDisplayNames = []string{
+{{- range .TerminalDisplayNames}}
+	{{quote .}},
+{{- end}}
+}
+
+var terminalIds = func() map[string]int {
+	ids := make(map[string]int, len(terminals))
+	for idx, terminal := range terminals {
+		ids[terminal] = idx
+	}
+	return ids
+}()
+
+var whitespacePattern = regexp.MustCompile("^\\s")
+var newlinePattern = regexp.MustCompile("^(\\n|\\r)")
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (def *tokenDef) match(input string) *Token {
+	loc := def.pattern.FindStringIndex(input)
+	if loc == nil {
+		return nil
+	}
+	match := input[:loc[1]]
+	if def.wholeWord {
+		last, _ := utf8.DecodeLastRuneInString(match)
+		next, size := utf8.DecodeRuneInString(input[len(match):])
+		if size > 0 && isWordRune(last) && isWordRune(next) {
+			return nil
+		}
+	}
+	return &Token{Type: def.typ, Value: match}
+}
+
+func matchGroup(defs []tokenDef, input string) *Token {
+	for idx := range defs {
+		if token := defs[idx].match(input); token != nil {
+			return token
+		}
+	}
+	return nil
+}
+
+func matchToken(input string) *Token {
+	if identifierToken < 0 {
+		for _, group := range [][]tokenDef{symbolTokens, keywordTokens, genericTokens} {
+			if token := matchGroup(group, input); token != nil {
+				return token
+			}
+		}
+		return nil
+	}
+
+	if token := matchGroup(symbolTokens, input); token != nil {
+		return token
+	}
+	if token := genericTokens[identifierToken].match(input); token != nil {
+		for _, keyword := range keywordTokens {
+			if keyword.exact.MatchString(token.Value) {
+				token.Type = keyword.typ
+				break
+			}
+		}
+		return token
+	}
+	if token := matchGroup(keywordTokens, input); token != nil {
+		return token
+	}
+	return matchGroup(genericTokens, input)
+}
+
+// Tokenize splits input into tokens terminated by an end of input token.
+func Tokenize(input string) ([]*Token, error) {
+	var tokens []*Token
+	var line, col uint
+	processed := 0
+
+	for processed < len(input) {
+		rest := input[processed:]
+		if newlinePattern.MatchString(rest) {
+			processed++
+			line++
+			col = 0
+			continue
+		}
+		if whitespacePattern.MatchString(rest) {
+			processed++
+			col++
+			continue
+		}
+
+		token := matchToken(rest)
+		if token == nil {
+			return nil, fmt.Errorf("Unrecognized symbol at %d:%d", line+1, col+1)
+		}
+		token.Line = line + 1
+		token.Col = col + 1
+		col += uint(len(token.Value))
+		processed += len(token.Value)
+		tokens = append(tokens, token)
+	}
+
+	return append(tokens, &Token{Type: eof, Line: line + 1, Col: col + 1}), nil
+}
+
+// SyntaxError reports a token that cannot follow the input parsed so far and the
+// terminals that would have been valid instead.
+type SyntaxError struct {
+	Token    *Token
+	Expected []string
+}
+
+func (e *SyntaxError) Error() string {
+	message := fmt.Sprintf("unexpected '%s' at %d:%d", e.Token.Value, e.Token.Line, e.Token.Col)
+	if e.Token.Type == eof {
+		message = fmt.Sprintf("unexpected end of input at %d:%d", e.Token.Line, e.Token.Col)
+	}
+	if len(e.Expected) == 0 {
+		return message
+	}
+
+	names := make([]string, len(e.Expected))
+	for idx, terminal := range e.Expected {
+		names[idx] = terminalDisplayNames[terminalIds[terminal]]
+	}
+	if len(names) == 1 {
+		return message + ", expected " + names[0]
+	}
+	return message + ", expected " + strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+{{end}}`
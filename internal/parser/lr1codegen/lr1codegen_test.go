@@ -0,0 +1,75 @@
+package lr1codegen_test
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/codegentest"
+	"interpreters/internal/parser/lr1codegen"
+	"interpreters/internal/parser/lr1grammar"
+	"testing"
+)
+
+const grammarConfigPath = "../lr1parser/grammar-config.json"
+
+func TestGenerate(t *testing.T) {
+	codegentest.CheckSource(t, func () ([]byte, error) {
+		return lr1codegen.GenerateFromJsonConfig(grammarConfigPath, lr1codegen.Options{PackageName: "jsonparser"})
+	})
+
+	if _, err := lr1codegen.GenerateFromJsonConfig(grammarConfigPath, lr1codegen.Options{}); (err == nil) {
+		t.Errorf("expected an error for a missing package name")
+	}
+}
+
+// Compiles the generated package and runs it against the inputs of the lr1parser tests.
+func TestGeneratedParser(t *testing.T) {
+	source, err := lr1codegen.GenerateFromJsonConfig(grammarConfigPath, lr1codegen.Options{PackageName: "main"})
+	if (err != nil) {
+		t.Fatal("Failed to generate parser: ", err.Error())
+	}
+
+	codegentest.RunParser(t, source, []string{
+		`true`,
+		`{ "a": [1, 2, { "b": null }], "c": false }`,
+		`[1, 2, ]`,
+		`{ "a": [`,
+		`{ @ }`,
+	}, []string{
+		"VALUE 1",
+		"VALUE 1",
+		"unexpected ']' at 1:8, expected '[', false, null, num_lit, str_lit, true or '{'",
+		"unexpected end of input at 1:9, expected '[', ']', false, null, num_lit, str_lit, true or '{'",
+		"Unrecognized symbol at 1:3",
+	})
+}
+
+// Like the runtime syntax errors, the generated ones never expect the error terminal.
+func TestGeneratedParserHidesErrorTerminal(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			GenericTokens: lexer.TokenConfigJsonArr{
+				{Type: "num_lit", Pattern: `(\d+)`},
+				{Type: "ident", Pattern: `([a-z]\w*)`},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"STMTS": lr1grammar.NewProductionsJson([]string{"STMT"}, []string{"STMTS", "STMT"}),
+			"STMT": lr1grammar.NewProductionsJson(
+				[]string{"ident", "'='", "num_lit", "';'"},
+				[]string{"error", "';'"},
+			),
+		},
+		StartSymbol: "STMTS",
+	}
+	source, err := lr1codegen.Generate(config, lr1codegen.Options{PackageName: "main"})
+	if (err != nil) {
+		t.Fatal("Failed to generate parser: ", err.Error())
+	}
+
+	codegentest.RunParser(t, source, []string{
+		`a = 1;`,
+		`= 1;`,
+	}, []string{
+		"STMTS 1",
+		"unexpected '=' at 1:1, expected ident",
+	})
+}
@@ -0,0 +1,115 @@
+package lr1codegen
+
+// Source of the generated package. Tables are emitted in the encoding described
+// by `encodeAction` and `sparseTable`.
+const parserTemplateSource = `// Code generated by lr1codegen. DO NOT EDIT.
+
+package {{.PackageName}}
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+{{template "lexer" .}}
+
+var nonTerminals = []string{
+{{- range .NonTerminals}}
+	{{quote .}},
+{{- end}}
+}
+
+var ruleNonTerminal = []int{ {{ints .RuleNonTerminal}} }
+
+var ruleLength = []int{ {{ints .RuleLength}} }
+
+// ACTION table: 0 accepts, s+1 shifts to state s, -(r+1) reduces by rule r
+var actionRowStart = []int{ {{ints .Action.RowStart}} }
+
+var actionColumns = []int{ {{ints .Action.Columns}} }
+
+var actionValues = []int{ {{ints .Action.Values}} }
+
+// GOTO table: next state per nonterminal
+var gotoRowStart = []int{ {{ints .Goto.RowStart}} }
+
+var gotoColumns = []int{ {{ints .Goto.Columns}} }
+
+var gotoValues = []int{ {{ints .Goto.Values}} }
+
+func lookup(rowStart []int, columns []int, values []int, row int, column int) (int, bool) {
+	for idx := rowStart[row]; idx < rowStart[row+1]; idx++ {
+		if columns[idx] == column {
+			return values[idx], true
+		}
+	}
+	return 0, false
+}
+
+// terminal of error productions, never listed as expected
+const errorTerminal = "error"
+
+func syntaxError(token *Token, state int) error {
+	expected := []string{}
+	for idx := actionRowStart[state]; idx < actionRowStart[state+1]; idx++ {
+		if terminal := terminals[actionColumns[idx]]; terminal != errorTerminal {
+			expected = append(expected, terminal)
+		}
+	}
+	return &SyntaxError{token, expected}
+}
+
+type stackEntry struct {
+	state int
+	node  *Node
+}
+
+// Parse tokenizes and parses input and returns its concrete syntax tree.
+func Parse(input string) (*Node, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := []stackEntry{ {0, nil} }
+	tokenIdx := 0
+	for {
+		token := tokens[tokenIdx]
+		state := stack[len(stack)-1].state
+		terminal, known := terminalIds[token.Type]
+		if !known {
+			return nil, syntaxError(token, state)
+		}
+		action, ok := lookup(actionRowStart, actionColumns, actionValues, state, terminal)
+		switch {
+		case !ok:
+			return nil, syntaxError(token, state)
+
+		case action == 0:
+			return stack[1].node, nil
+
+		case action > 0:
+			stack = append(stack, stackEntry{action - 1, &Node{Symbol: token.Type, RuleId: -1, Token: token}})
+			tokenIdx++
+
+		default:
+			ruleId := -action - 1
+			length := ruleLength[ruleId]
+			node := &Node{Symbol: nonTerminals[ruleNonTerminal[ruleId]], RuleId: ruleId, Children: []*Node{} }
+			for _, entry := range stack[len(stack)-length:] {
+				node.Children = append(node.Children, entry.node)
+			}
+			stack = stack[:len(stack)-length]
+
+			next, ok := lookup(gotoRowStart, gotoColumns, gotoValues, stack[len(stack)-1].state, ruleNonTerminal[ruleId])
+			if !ok {
+				return nil, fmt.Errorf("Corrupt parsing table: no goto for %s", node.Symbol)
+			}
+			stack = append(stack, stackEntry{next, node})
+		}
+	}
+}
+`
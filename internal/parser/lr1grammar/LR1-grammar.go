@@ -7,19 +7,29 @@ import (
 	"interpreters/internal/lexer"
 	"interpreters/internal/symbols"
 	"interpreters/utilities/arrays"
+	"interpreters/utilities/bitsets"
 	"interpreters/utilities/files"
 	"interpreters/utilities/sets"
+	"slices"
+	"sort"
+	"strings"
 )
 
 type GrammarConfigJson struct {
 	Terminals 		lexer.LexerConfigJson	`json:"terminals"`
-	NonTerminals 	map[string][][]string	`json:"nonTerminals"`
+	NonTerminals 	map[string][]ProductionJson	`json:"nonTerminals"`
 	StartSymbol		string					`json:"startSymbol"`
+	// names of terminals as shown in error messages, e.g. "str_lit": "string"
+	DisplayNames	map[string]string		`json:"displayNames,omitempty"`
+
+	// set by `ResolveLiteralTerminals`: the productions hold no literal terminals
+	literalsResolved bool
 }
 
 type ProductionRule struct {
 	NonTerminal 	string
 	Production 		[]string
+	Annotations		ProductionAnnotations
 }
 
 type Grammar struct {
@@ -28,44 +38,169 @@ type Grammar struct {
 	AllSymbols					sets.Set[string]
 	StartSymbol					string
 	ProductionRules				map[uint]ProductionRule
+	Symbols						*SymbolTable
+	// see `GrammarConfigJson.DisplayNames`
+	DisplayNames				map[string]string
 
 	productionRulesIdx 			map[string]*[]uint
 	productionRulesInvertedIdx 	map[string]*[]uint
+
+	// integer view of the production rules, see `internSymbols`
+	ruleLHS						[]int
+	ruleRHS						[][]int
+	rulesOf						[][]int
+	nullable					bitsets.Bitset
+	first						[]bitsets.Bitset
 }
 
-func NewAugmentedGrammar(config GrammarConfigJson) *Grammar {
-	// add symbols.AugmentedStart
-	config.NonTerminals[symbols.AugmentedStart] = [][]string{{config.StartSymbol}}
+func NewAugmentedGrammar(config GrammarConfigJson) (*Grammar, error) {
+	// add symbols.AugmentedStart to a copy of the non-terminals so that the caller's
+	// config is left untouched
+	nonTerminals := make(map[string][]ProductionJson, len(config.NonTerminals) + 1)
+	for nonTerminal, productionRules := range config.NonTerminals {
+		nonTerminals[nonTerminal] = productionRules
+	}
+	nonTerminals[symbols.AugmentedStart] = []ProductionJson{NewProductionJson(config.StartSymbol)}
+	config.NonTerminals = nonTerminals
 	config.StartSymbol = symbols.AugmentedStart
 	return NewGrammar(config)
 }
 
-func NewGrammar(config GrammarConfigJson) *Grammar {
-	terminals := sets.NewEmptySet[string]()
-	nonTerminals := sets.NewEmptySet[string]()
-	enumeratedProductionRules := make(map[uint]ProductionRule)
-
-	// maps non-terminal -> production rule of non-terminal
-	enumeratedProductionRulesIdx := make(map[string]*[]uint)
-	
-	// maps any symbol -> production rule containing symbol
-	enumeratedProductionRulesInvertedIdx := make(map[string]*[]uint)
+func NewGrammar(config GrammarConfigJson) (*Grammar, error) {
+	config, err := ResolveLiteralTerminals(config)
+	if err != nil {
+		return nil, err
+	}
+	terminals := []string{}
+	nonTerminals := []string{}
+	productionRules := []ProductionRule{}
 
-	// load all token types uinto terminals set
+	// load all token types into terminals
 	for _, token := range config.Terminals.SymbolTokens {
-		terminals.Add(token.Type)
+		terminals = append(terminals, token.Type)
 	}
 	for _, token := range config.Terminals.KeywordTokens {
-		terminals.Add(token.Type)
+		terminals = append(terminals, token.Type)
 	}
 	for _, token := range config.Terminals.GenericTokens {
-		terminals.Add(token.Type)
+		terminals = append(terminals, token.Type)
+	}
+
+	// enumerate production rules. Non-terminals are enumerated in a fixed order
+	// so that rule IDs are stable across runs.
+	for nonTerminal := range config.NonTerminals {
+		nonTerminals = append(nonTerminals, nonTerminal)
+	}
+	SortNonTerminals(nonTerminals)
+	for _, nonTerminal := range nonTerminals {
+		for _, productionRule := range config.NonTerminals[nonTerminal] {
+			productionRules = append(productionRules, ProductionRule{
+				nonTerminal,
+				productionRule.Symbols,
+				productionRule.ProductionAnnotations,
+			})
+		}
+	}
+
+	grammar, err := NewGrammarFromRules(terminals, nonTerminals, config.StartSymbol, productionRules)
+	if err != nil {
+		return nil, err
+	}
+	for terminal, displayName := range config.DisplayNames {
+		grammar.DisplayNames[terminal] = displayName
+	}
+	return grammar, nil
+}
+
+// Same as `NewGrammar` but panics if the config is invalid. Meant for grammars known
+// to be valid, e.g. in tests.
+func MustNewGrammar(config GrammarConfigJson) *Grammar {
+	grammar, err := NewGrammar(config)
+	if err != nil {
+		panic(err.Error())
+	}
+	return grammar
+}
+
+// Same as `NewAugmentedGrammar` but panics if the config is invalid.
+func MustNewAugmentedGrammar(config GrammarConfigJson) *Grammar {
+	grammar, err := NewAugmentedGrammar(config)
+	if err != nil {
+		panic(err.Error())
+	}
+	return grammar
+}
+
+/*
+Builds the lexer and the grammar of a `GrammarConfigJson`, the shared setup of the
+parser backends: literal terminals are resolved into lexer tokens, then the grammar
+terminals and annotations are validated against the lexer config. The grammar is
+augmented if `augmented` is set.
+*/
+func NewLexerAndGrammar(config GrammarConfigJson, augmented bool) (*lexer.Lexer, *Grammar, error) {
+	// literal terminals in productions also need lexer tokens
+	config, err := ResolveLiteralTerminals(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lex, err := lexer.CreateLexer(config.Terminals)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newGrammar := NewGrammar
+	if (augmented) {
+		newGrammar = NewAugmentedGrammar
 	}
+	grammar, err := newGrammar(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = grammar.ValidateTerminals()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = grammar.ValidateAnnotations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lex, grammar, nil
+}
+
+// Sorts non-terminals in the order their production rules are enumerated in: the
+// augmented start first, then by name.
+func SortNonTerminals(nonTerminals []string) {
+	sort.Slice(nonTerminals, func (i int, j int) bool {
+		if (nonTerminals[j] == symbols.AugmentedStart) {
+			return false
+		}
+		return nonTerminals[i] == symbols.AugmentedStart || nonTerminals[i] < nonTerminals[j]
+	})
+}
+
+/*
+Builds a `Grammar` from an already enumerated list of production rules: the ID of
+each rule is its index in `productionRules`. Used to restore a `Grammar` exactly as
+it was built, e.g. when loading serialized parsing tables. An error is returned if a
+terminal is a reserved symbol.
+*/
+func NewGrammarFromRules(terminalSymbols []string, nonTerminalSymbols []string, startSymbol string, productionRules []ProductionRule) (*Grammar, error) {
+	terminals := sets.NewSet(terminalSymbols...)
+	nonTerminals := sets.NewSet(nonTerminalSymbols...)
+	enumeratedProductionRules := make(map[uint]ProductionRule)
+
+	// maps non-terminal -> production rule of non-terminal
+	enumeratedProductionRulesIdx := make(map[string]*[]uint)
+	
+	// maps any symbol -> production rule containing symbol
+	enumeratedProductionRulesInvertedIdx := make(map[string]*[]uint)
 
 	// verify that no symbols are reserved keywords
 	for _, terminal := range terminals.GetItems() {
-		if (terminal == symbols.EOF || terminal == symbols.Dot) {
-			panic(fmt.Sprintf("Grammar cannot use the reserved symbol: %s", terminal))
+		if (terminal == symbols.EOF || terminal == symbols.Dot || terminal == symbols.Error) {
+			return nil, fmt.Errorf("Grammar cannot use the reserved symbol: %s", terminal)
 		}
 	}
 
@@ -73,28 +208,33 @@ func NewGrammar(config GrammarConfigJson) *Grammar {
 	terminals.Add(symbols.Epsilon)
 	terminals.Add(symbols.EOF)
 
-	// load all non-terminals uinto nonTerminals set
-	for nonTerminal := range config.NonTerminals {
-		nonTerminals.Add(nonTerminal)
+	// the error terminal has no token: it is only a terminal of grammars that use it
+	for _, productionRule := range productionRules {
+		if (slices.Contains(productionRule.Production, symbols.Error)) {
+			terminals.Add(symbols.Error)
+			break
+		}
 	}
 
 	// enumerate production rules and create forward index
-	var i uint
-	for nonTerminal, productionRules := range config.NonTerminals {
-		index := make([]uint, len(productionRules))
-		enumeratedProductionRulesIdx[nonTerminal] = &index
-		for j, productionRule := range productionRules {
-			enumeratedProductionRules[i] = ProductionRule{
-				nonTerminal,
-				productionRule,
-			}
-			index[j] = i
-			i++
+	for _, nonTerminal := range nonTerminalSymbols {
+		enumeratedProductionRulesIdx[nonTerminal] = &[]uint{}
+	}
+	for idx, productionRule := range productionRules {
+		ruleId := uint(idx)
+		nonTerminals.Add(productionRule.NonTerminal)
+		enumeratedProductionRules[ruleId] = productionRule
+		index, exists := enumeratedProductionRulesIdx[productionRule.NonTerminal]
+		if (!exists) {
+			index = &[]uint{}
+			enumeratedProductionRulesIdx[productionRule.NonTerminal] = index
 		}
+		*index = append(*index, ruleId)
 	}
 
 	// create inverted index
-	for ruleId, productionRule := range enumeratedProductionRules {
+	for idx, productionRule := range productionRules {
+		ruleId := uint(idx)
 		for _, symbol := range productionRule.Production {
 			invertedIndex, exists := enumeratedProductionRulesInvertedIdx[symbol]
 			if exists {
@@ -105,45 +245,60 @@ func NewGrammar(config GrammarConfigJson) *Grammar {
 		}
 	}
 
-	return &Grammar{
+	grammar := &Grammar{
 		terminals,
 		nonTerminals,
 		terminals.Union(nonTerminals),
-		config.StartSymbol,
+		startSymbol,
 		enumeratedProductionRules,
+		nil,
+		map[string]string{},
 		enumeratedProductionRulesIdx,
 		enumeratedProductionRulesInvertedIdx,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 	}
+	grammar.internSymbols(terminals.GetItems(), nonTerminals.GetItems())
+
+	return grammar, nil
 }
 
-func NewAugmentedGrammarFromJsonConfig(path string) (*Grammar, error) {
+// Reads and unmarshals a `GrammarConfigJson` file.
+func ReadGrammarConfigJson(path string) (GrammarConfigJson, error) {
+	var data GrammarConfigJson
+
 	bytes, err := files.OpenFileToByteStream(path)
 	if err != nil {
-		return nil, err
+		return data, err
 	}
 
-	var data GrammarConfigJson
 	err = json.Unmarshal(bytes, &data)
-    if err != nil {
-		return nil, errors.New(`Error unmarshalling config file: ` + err.Error())
-    }
+	if err != nil {
+		return data, errors.New(`Error unmarshalling config file: ` + err.Error())
+	}
 
-	return NewAugmentedGrammar(data), nil
+	return data, nil
 }
 
-func NewGrammarFromJsonConfig(path string) (*Grammar, error) {
-	bytes, err := files.OpenFileToByteStream(path)
+func NewAugmentedGrammarFromJsonConfig(path string) (*Grammar, error) {
+	data, err := ReadGrammarConfigJson(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var data GrammarConfigJson
-	err = json.Unmarshal(bytes, &data)
-    if err != nil {
-		return nil, errors.New(`Error unmarshalling config file: ` + err.Error())
-    }
+	return NewAugmentedGrammar(data)
+}
+
+func NewGrammarFromJsonConfig(path string) (*Grammar, error) {
+	data, err := ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
 
-	return NewGrammar(data), nil
+	return NewGrammar(data)
 }
 
 // ----- GRAMMAR METHODS -----
@@ -175,56 +330,87 @@ func (g *Grammar) GetProductionsDerivingSymbol(symbol string) []ProductionRule {
 // Find the ID of a production rule as registered in the `Grammar`. Returns -1 if
 // the queried production rule does not exist.
 func (g *Grammar) GetProductionId(LHS string, RHS []string) (int, error) {
-	var possibleIds sets.Set[uint]
-
 	// get all production IDs for the non-terminal
 	pForwardIndex, exists := g.productionRulesIdx[LHS]
 	if (!exists) {
 		return -1, fmt.Errorf(`Non-terminal: %s does not exist in the specified grammar.`, LHS)
-	} else {
-		forwardIndex := *pForwardIndex
-		possibleIds = sets.NewSet(forwardIndex...)
 	}
 
-	// get all possible production IDs for RHS symbols
+	// all RHS symbols must be registered in the grammar
 	for _, symbol :=  range RHS {
-		pInvertedIndex, exists := g.productionRulesInvertedIdx[symbol]
-		if (!exists) {
+		if _, exists := g.productionRulesInvertedIdx[symbol]; !exists {
 			return -1, fmt.Errorf(`symbol: %s does not exist in the specified grammar`, symbol)
-		} else {
-			invertedIndex := *pInvertedIndex
-			invertedIndexSet := sets.NewSet(invertedIndex...)
-			possibleIds = possibleIds.Intersection(invertedIndexSet)
 		}
 	}
 
-	// there should be no ambiguity in resolving the ID of a production
-	if possibleIds.Size() > 1 {
-		return -1, errors.New(`production rule maps to multiple ambiguous IDs`)
-	} else if possibleIds.Size() == 0 {
-		return -1, errors.New(`production rule not found in the specified grammar`)
-	} else {
-		return int(possibleIds.GetItems()[0]), nil
+	// find the production of the non-terminal with exactly the same RHS
+	for _, ruleId := range *pForwardIndex {
+		if (slices.Equal(g.ProductionRules[ruleId].Production, RHS)) {
+			return int(ruleId), nil
+		}
 	}
+
+	return -1, errors.New(`production rule not found in the specified grammar`)
 }
 
-func (g *Grammar) DerivesEpsilon(symbol string) bool {
-	// If symbol is not a non-terminal, it never derives epsilon
-	if !g.NonTerminals.Has(symbol) {
-		return false
+// Number of symbols a production rule pushes onto the parser stack - 0 for
+// Epsilon productions.
+func (rule ProductionRule) Length() int {
+	if (len(rule.Production) == 1 && rule.Production[0] == symbols.Epsilon) {
+		return 0
 	}
+	return len(rule.Production)
+}
 
-	productionRules := g.GetProductionsOfNonTerminal(symbol)
-	for _, productionRule := range productionRules {
-		// Check if this production is a single epsilon
-		if len(productionRule.Production) == 1 && productionRule.Production[0] == symbols.Epsilon {
-			return true
+// Verifies that every terminal used in the productions has a token definition and
+// that every token definition is used by at least one production.
+func (g *Grammar) ValidateTerminals() error {
+	problems := []string{}
+
+	for symbol := range g.productionRulesInvertedIdx {
+		if (!g.AllSymbols.Has(symbol)) {
+			problems = append(problems, fmt.Sprintf(`symbol: %s has no token definition`, symbol))
+		}
+	}
+	for _, terminal := range g.Terminals.GetItems() {
+		if (terminal == symbols.Epsilon || terminal == symbols.EOF) {
+			continue
+		}
+		if _, used := g.productionRulesInvertedIdx[terminal]; !used {
+			problems = append(problems, fmt.Sprintf(`token: %s is not used by any production`, terminal))
 		}
 	}
 
-	return false
+	if (len(problems) > 0) {
+		sort.Strings(problems)
+		return errors.New(`Grammar terminals do not match token definitions: ` + strings.Join(problems, `; `))
+	}
+	return nil
 }
 
+// Get the name of a terminal as shown in error messages, see `TerminalDisplayName`.
+func (g *Grammar) DisplayName(terminal string) string {
+	return TerminalDisplayName(g.DisplayNames, terminal)
+}
 
+// Get the name of a terminal as shown in error messages: its display name in
+// `displayNames` if any, "end of input" for EOF, the terminal itself if it is a word
+// and the terminal in single quotes otherwise, e.g. `','`.
+func TerminalDisplayName(displayNames map[string]string, terminal string) string {
+	if displayName, exists := displayNames[terminal]; exists {
+		return displayName
+	}
+	if (terminal == symbols.EOF) {
+		return "end of input"
+	}
+	if (wordPattern.MatchString(terminal)) {
+		return terminal
+	}
+	return "'" + terminal + "'"
+}
 
-
+func (g *Grammar) DerivesEpsilon(symbol string) bool {
+	// If symbol is not a non-terminal, it never derives epsilon
+	id, exists := g.Symbols.Id(symbol)
+	return exists && !g.Symbols.IsTerminal(id) && g.nullable.Has(id)
+}
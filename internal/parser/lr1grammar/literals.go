@@ -0,0 +1,116 @@
+package lr1grammar
+
+import (
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/utilities/arrays"
+	"regexp"
+	"sort"
+)
+
+// Words: literal terminals that become keyword tokens and terminals shown unquoted.
+var wordPattern = regexp.MustCompile(`^\w+$`)
+
+// Whether a production symbol is a quoted literal terminal such as `'{'` or `"null"`.
+func IsLiteralTerminal(symbol string) bool {
+	if (len(symbol) < 3) {
+		return false
+	}
+	quote := symbol[0]
+	return (quote == '\'' || quote == '"') && symbol[len(symbol) - 1] == quote
+}
+
+/*
+Replaces quoted literal terminals in the productions of a `GrammarConfigJson` with
+plain terminals named after the literal text, and registers a lexer token for every
+literal that has no token definition yet. Word-like literals become whole word
+keyword tokens, everything else becomes a symbol token.
+
+Returns a new config; the original is left untouched. Resolving an already
+resolved config is a no-op. An error is returned if a literal names a non-terminal.
+*/
+func ResolveLiteralTerminals(config GrammarConfigJson) (GrammarConfigJson, error) {
+	// the symbols of a resolved config may still look quoted, e.g. the terminal `"a"`
+	// of the literal `'"a"'`
+	if (config.literalsResolved) {
+		return config, nil
+	}
+
+	definedTokens := make(map[string]bool)
+	for _, tokenGroup := range []lexer.TokenConfigJsonArr{
+		config.Terminals.SymbolTokens,
+		config.Terminals.KeywordTokens,
+		config.Terminals.GenericTokens,
+	} {
+		for _, token := range tokenGroup {
+			definedTokens[token.Type] = true
+		}
+	}
+
+	terminals := config.Terminals
+	terminals.SymbolTokens = append(lexer.TokenConfigJsonArr{}, terminals.SymbolTokens...)
+	terminals.KeywordTokens = append(lexer.TokenConfigJsonArr{}, terminals.KeywordTokens...)
+
+	// visit non-terminals in a fixed order so that tokens are registered deterministically
+	orderedNonTerminals := make([]string, 0, len(config.NonTerminals))
+	for nonTerminal := range config.NonTerminals {
+		orderedNonTerminals = append(orderedNonTerminals, nonTerminal)
+	}
+	sort.Strings(orderedNonTerminals)
+
+	// resolves a single symbol, registering a token for new literals
+	var clash error
+	resolveSymbol := func (symbol string) string {
+		if (!IsLiteralTerminal(symbol)) {
+			return symbol
+		}
+
+		literal := symbol[1:len(symbol) - 1]
+		if _, isNonTerminal := config.NonTerminals[literal]; isNonTerminal {
+			if (clash == nil) {
+				clash = fmt.Errorf("Literal terminal %s clashes with the non-terminal: %s", symbol, literal)
+			}
+			return literal
+		}
+		if (!definedTokens[literal]) {
+			definedTokens[literal] = true
+			tokenConfig := lexer.TokenConfigJson{
+				Type: literal,
+				Pattern: regexp.QuoteMeta(literal),
+			}
+			if (wordPattern.MatchString(literal)) {
+				tokenConfig.WholeWord = true
+				terminals.KeywordTokens = append(terminals.KeywordTokens, tokenConfig)
+			} else {
+				terminals.SymbolTokens = append(terminals.SymbolTokens, tokenConfig)
+			}
+		}
+		return literal
+	}
+
+	nonTerminals := make(map[string][]ProductionJson, len(config.NonTerminals))
+	for _, nonTerminal := range orderedNonTerminals {
+		productionRules := config.NonTerminals[nonTerminal]
+		resolvedRules := make([]ProductionJson, len(productionRules))
+
+		for i, productionRule := range productionRules {
+			resolvedRule := productionRule
+			resolvedRule.Symbols = arrays.Map(productionRule.Symbols, resolveSymbol)
+			if (productionRule.Drop != nil) {
+				resolvedRule.Drop = arrays.Map(productionRule.Drop, resolveSymbol)
+			}
+			resolvedRules[i] = resolvedRule
+		}
+
+		nonTerminals[nonTerminal] = resolvedRules
+	}
+
+	if (clash != nil) {
+		return config, clash
+	}
+
+	config.Terminals = terminals
+	config.NonTerminals = nonTerminals
+	config.literalsResolved = true
+	return config, nil
+}
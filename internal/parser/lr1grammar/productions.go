@@ -0,0 +1,116 @@
+package lr1grammar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+/*
+Annotations that shape the tree built for a production:
+
+  - `node` renames the node built for the production, e.g. "Entry".
+  - `inline` splices the children of the node into its parent instead.
+  - `drop` omits the children produced by the listed RHS symbols, e.g. [",", ":"].
+  - `fields` names children by the index of their RHS symbol, e.g. {"key": 0}.
+*/
+type ProductionAnnotations struct {
+	Node 	string 			`json:"node,omitempty"`
+	Inline 	bool 			`json:"inline,omitempty"`
+	Drop 	[]string 		`json:"drop,omitempty"`
+	Fields 	map[string]int 	`json:"fields,omitempty"`
+}
+
+func (annotations *ProductionAnnotations) IsEmpty() bool {
+	return annotations.Node == "" && !annotations.Inline && len(annotations.Drop) == 0 && len(annotations.Fields) == 0
+}
+
+/*
+A production of a non-terminal in a `GrammarConfigJson`. Productions are written
+either as a plain array of symbols or, when annotated, as an object:
+
+	["KEY", ":", "VALUE"]
+	{ "symbols": ["KEY", ":", "VALUE"], "node": "Entry", "drop": [":"] }
+*/
+type ProductionJson struct {
+	Symbols []string `json:"symbols"`
+	ProductionAnnotations
+}
+
+func NewProductionJson(symbols ...string) ProductionJson {
+	return ProductionJson{Symbols: symbols}
+}
+
+// Wraps plain symbol arrays as unannotated productions.
+func NewProductionsJson(productions ...[]string) []ProductionJson {
+	productionsJson := make([]ProductionJson, len(productions))
+	for idx, production := range productions {
+		productionsJson[idx] = NewProductionJson(production...)
+	}
+	return productionsJson
+}
+
+func (production *ProductionJson) UnmarshalJSON(data []byte) error {
+	if (bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))) {
+		*production = ProductionJson{}
+		return json.Unmarshal(data, &production.Symbols)
+	}
+
+	// alias without methods to avoid recursing into this `UnmarshalJSON`
+	type productionJsonObject ProductionJson
+	var object productionJsonObject
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*production = ProductionJson(object)
+	return nil
+}
+
+func (production ProductionJson) MarshalJSON() ([]byte, error) {
+	if (production.IsEmpty()) {
+		return json.Marshal(production.Symbols)
+	}
+
+	type productionJsonObject ProductionJson
+	return json.Marshal(productionJsonObject(production))
+}
+
+// Verifies that the annotations of every production refer to symbols of the
+// production they annotate.
+func (g *Grammar) ValidateAnnotations() error {
+	problems := []string{}
+
+	for ruleId := 0; ruleId < len(g.ProductionRules); ruleId++ {
+		rule := g.ProductionRules[uint(ruleId)]
+		annotations := rule.Annotations
+		name := rule.NonTerminal + " -> " + strings.Join(rule.Production, " ")
+
+		if (annotations.Inline && annotations.Node != "") {
+			problems = append(problems, fmt.Sprintf(`%s: cannot both be inlined and renamed`, name))
+		}
+		if (annotations.Inline && len(annotations.Fields) > 0) {
+			problems = append(problems, fmt.Sprintf(`%s: inlined productions cannot name fields`, name))
+		}
+		for _, symbol := range annotations.Drop {
+			if (!slices.Contains(rule.Production, symbol)) {
+				problems = append(problems, fmt.Sprintf(`%s: dropped symbol %s is not in the production`, name, symbol))
+			}
+		}
+		for field, idx := range annotations.Fields {
+			if (idx < 0 || idx >= rule.Length()) {
+				problems = append(problems, fmt.Sprintf(`%s: field %s refers to a missing symbol %d`, name, field, idx))
+			} else if (slices.Contains(annotations.Drop, rule.Production[idx])) {
+				problems = append(problems, fmt.Sprintf(`%s: field %s refers to a dropped symbol`, name, field))
+			}
+		}
+	}
+
+	if (len(problems) > 0) {
+		sort.Strings(problems)
+		return fmt.Errorf(`Invalid production annotations: %s`, strings.Join(problems, `; `))
+	}
+	return nil
+}
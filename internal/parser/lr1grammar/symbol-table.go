@@ -0,0 +1,201 @@
+package lr1grammar
+
+import (
+	"interpreters/internal/symbols"
+	"interpreters/utilities/bitsets"
+	"sort"
+)
+
+/*
+Dense integer IDs for the symbols of a `Grammar`. Terminals come first, sorted and
+followed by EOF, then non-terminals, sorted and followed by the augmented start if
+present. Epsilon has no ID: Epsilon productions have an empty RHS.
+
+Symbols used in productions without being defined are numbered as terminals.
+*/
+type SymbolTable struct {
+	names []string
+	ids map[string]int
+	numTerminals int
+}
+
+func newSymbolTable(terminals []string, nonTerminals []string) *SymbolTable {
+	ordered := func (names []string, last string) []string {
+		result := []string{}
+		hasLast := false
+		for _, name := range names {
+			if (name == last) {
+				hasLast = true
+			} else if (name != symbols.Epsilon) {
+				result = append(result, name)
+			}
+		}
+		sort.Strings(result)
+		if (hasLast) {
+			result = append(result, last)
+		}
+		return result
+	}
+
+	terminals = ordered(terminals, symbols.EOF)
+	table := SymbolTable{
+		append(terminals, ordered(nonTerminals, symbols.AugmentedStart)...),
+		make(map[string]int),
+		len(terminals),
+	}
+	for id, name := range table.names {
+		table.ids[name] = id
+	}
+	return &table
+}
+
+// Get the ID of a symbol. Returns false for unknown symbols and Epsilon.
+func (st *SymbolTable) Id(name string) (int, bool) {
+	id, exists := st.ids[name]
+	return id, exists
+}
+
+func (st *SymbolTable) Name(id int) string {
+	return st.names[id]
+}
+
+// Number of symbols, terminals and non-terminals.
+func (st *SymbolTable) Len() int {
+	return len(st.names)
+}
+
+// Number of terminals: terminal IDs are 0 to `NumTerminals() - 1`.
+func (st *SymbolTable) NumTerminals() int {
+	return st.numTerminals
+}
+
+func (st *SymbolTable) IsTerminal(id int) bool {
+	return id < st.numTerminals
+}
+
+// Get the names of the symbols in a set of IDs, in ID order.
+func (st *SymbolTable) Names(ids bitsets.Bitset) []string {
+	names := []string{}
+	for _, id := range ids.Items() {
+		names = append(names, st.names[id])
+	}
+	return names
+}
+
+// ----- INTEGER VIEW OF THE GRAMMAR -----
+
+// Interns the symbols of every production rule and computes nullable symbols and
+// FIRST sets over symbol IDs.
+func (g *Grammar) internSymbols(terminals []string, nonTerminals []string) {
+	// undefined symbols used in productions are treated as terminals
+	undefined := []string{}
+	for symbol := range g.productionRulesInvertedIdx {
+		if (!g.AllSymbols.Has(symbol)) {
+			undefined = append(undefined, symbol)
+		}
+	}
+	g.Symbols = newSymbolTable(append(terminals, undefined...), nonTerminals)
+
+	numRules := len(g.ProductionRules)
+	g.ruleLHS = make([]int, numRules)
+	g.ruleRHS = make([][]int, numRules)
+	g.rulesOf = make([][]int, g.Symbols.Len() - g.Symbols.NumTerminals())
+	for ruleId := 0; ruleId < numRules; ruleId++ {
+		productionRule := g.ProductionRules[uint(ruleId)]
+		lhs, _ := g.Symbols.Id(productionRule.NonTerminal)
+		rhs := []int{}
+		for _, symbol := range productionRule.Production {
+			if id, exists := g.Symbols.Id(symbol); exists {
+				rhs = append(rhs, id)
+			}
+		}
+		g.ruleLHS[ruleId] = lhs
+		g.ruleRHS[ruleId] = rhs
+		g.rulesOf[lhs - g.Symbols.NumTerminals()] = append(g.rulesOf[lhs - g.Symbols.NumTerminals()], ruleId)
+	}
+
+	g.computeNullable()
+	g.computeFIRST()
+}
+
+// Computes the set of non-terminals that derive Epsilon, directly or through
+// other nullable non-terminals.
+func (g *Grammar) computeNullable() {
+	g.nullable = bitsets.New(g.Symbols.Len())
+	changed := true
+	for changed {
+		changed = false
+		for ruleId, lhs := range g.ruleLHS {
+			if (g.nullable.Has(lhs)) {
+				continue
+			}
+			allNullable := true
+			for _, symbol := range g.ruleRHS[ruleId] {
+				if (!g.nullable.Has(symbol)) {
+					allNullable = false
+					break
+				}
+			}
+			if (allNullable) {
+				g.nullable.Add(lhs)
+				changed = true
+			}
+		}
+	}
+}
+
+// Computes FIRST(symbol) for every symbol as a set of terminal IDs. Epsilon is never
+// part of a FIRST set, see `IsNullable`.
+func (g *Grammar) computeFIRST() {
+	numTerminals := g.Symbols.NumTerminals()
+	g.first = make([]bitsets.Bitset, g.Symbols.Len())
+	for id := range g.first {
+		g.first[id] = bitsets.New(numTerminals)
+		if (id < numTerminals) {
+			g.first[id].Add(id)
+		}
+	}
+
+	changed := true
+	for changed {
+		changed = false
+		for ruleId, lhs := range g.ruleLHS {
+			for _, symbol := range g.ruleRHS[ruleId] {
+				if (g.first[lhs].UnionWith(g.first[symbol])) {
+					changed = true
+				}
+				if (!g.nullable.Has(symbol)) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Get the ID of the non-terminal a production rule derives.
+func (g *Grammar) RuleLHS(ruleId int) int {
+	return g.ruleLHS[ruleId]
+}
+
+// Get the symbol IDs of the production of a rule, empty for Epsilon productions.
+func (g *Grammar) RuleRHS(ruleId int) []int {
+	return g.ruleRHS[ruleId]
+}
+
+// Get the IDs of the production rules of a non-terminal.
+func (g *Grammar) RulesOf(nonTerminal int) []int {
+	if (nonTerminal < g.Symbols.NumTerminals()) {
+		return nil
+	}
+	return g.rulesOf[nonTerminal - g.Symbols.NumTerminals()]
+}
+
+// Whether the symbol with the given ID derives Epsilon.
+func (g *Grammar) IsNullable(id int) bool {
+	return g.nullable.Has(id)
+}
+
+// Get FIRST(symbol) of the symbol with the given ID. The set must not be modified.
+func (g *Grammar) FIRST(id int) bitsets.Bitset {
+	return g.first[id]
+}
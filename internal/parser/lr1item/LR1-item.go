@@ -1,96 +1,85 @@
 package lr1item
 
 import (
-	"errors"
-	"fmt"
+	"interpreters/internal/parser/lr1grammar"
 	"interpreters/internal/symbols"
-	"interpreters/utilities/sets"
+	"interpreters/utilities/bitsets"
+	"sort"
 	"strings"
 )
 
-type LR1Item struct {
-	LHS string
-	RHS []string
-	LookaheadSet sets.Set[string]
-	DotPosition int
-	OriginalRHS []string
+// Identity of an item: a production rule and the position of the `dot` in its RHS.
+type Core struct {
+	Rule int
+	Dot int
 }
 
+// LR(1) item over the symbol IDs of a `Grammar`. Lookaheads are terminal IDs.
+type LR1Item struct {
+	Core
+	Lookaheads bitsets.Bitset
+}
 
-// Generates a new `LR1Item`.
-func NewLR1Item(LHS string, RHS []string, dotPosition int, lookaheadSet sets.Set[string]) (*LR1Item, error) {
-	RHSLength := len(RHS)
-
-	// ensure dotPosition is valid
-	if dotPosition > RHSLength || dotPosition < 0 {
-		message := fmt.Sprintf(
-			`dotPostion (%d) needs to be between 0 and %d (length of RHS).`, 
-			dotPosition, 
-			RHSLength,
-		)
-		return nil, errors.New(message)
-	}
-
-	// create new copies of each array for manipulation
-	RHSLeft := RHS
-	RHSRight := RHS
-	RHSWithDot := []string{}
-
-	RHSLeft = RHSLeft[0:dotPosition]
-	RHSRight = RHSRight[dotPosition:RHSLength]
-	RHSWithDot = append(RHSWithDot, RHSLeft...)
-	RHSWithDot = append(RHSWithDot, symbols.Dot)
-	RHSWithDot = append(RHSWithDot, RHSRight...)
-
-	return &LR1Item{
-		LHS,
-		RHSWithDot,
-		lookaheadSet,
-		dotPosition,
-		RHS,
-	}, nil
+// Generates a new `LR1Item`. The dot position must be between 0 and the length of
+// the rule's RHS.
+func NewLR1Item(rule int, dot int, lookaheads bitsets.Bitset) *LR1Item {
+	return &LR1Item{Core{rule, dot}, lookaheads}
 }
 
-// Get the symbol immediately right of the `dot`. Returns `Epsilon` if the RHS 
+// Get the ID of the symbol immediately right of the `dot`. Returns -1 if the RHS
 // is complete (`dot` at the end of the production).
-func (item *LR1Item) GetNextSymbol() string {
-	if item.DotPosition == len(item.RHS) - 1 {
-		return symbols.Epsilon
-	} else {
-		return item.RHS[item.DotPosition + 1]
+func (item *LR1Item) NextSymbol(grammar *lr1grammar.Grammar) int {
+	rhs := grammar.RuleRHS(item.Rule)
+	if (item.Dot >= len(rhs)) {
+		return -1
 	}
+	return rhs[item.Dot]
 }
 
-func (item *LR1Item) ProductionIsComplete() bool {
-	return item.GetNextSymbol() == symbols.Epsilon
+func (item *LR1Item) IsComplete(grammar *lr1grammar.Grammar) bool {
+	return item.Dot >= len(grammar.RuleRHS(item.Rule))
 }
 
-// Retrieves the context sequence for the next symbol in the production rule.
-func (item *LR1Item) GetContextForNextSymbol() []string {
-	if item.GetNextSymbol() == symbols.Epsilon {
-		return []string{}
-	} else {
-		return item.RHS[item.DotPosition + 2:]
+// Retrieves the context sequence for the next symbol in the production rule: the
+// symbols after it.
+func (item *LR1Item) ContextForNextSymbol(grammar *lr1grammar.Grammar) []int {
+	rhs := grammar.RuleRHS(item.Rule)
+	if (item.Dot + 1 >= len(rhs)) {
+		return nil
 	}
+	return rhs[item.Dot + 1:]
+}
+
+// Returns a new `LR1Item` with the `dot` advanced by one position in the RHS. The
+// lookahead set is shared with this item.
+func (item *LR1Item) AdvanceDot() *LR1Item {
+	return NewLR1Item(item.Rule, item.Dot + 1, item.Lookaheads)
 }
 
-// Returns a new `LR1Item` with the `dot` advanced by one position in the RHS.
-// Also returns the context for the symbol processed by the parser when advancing.
-func (item *LR1Item) AdvanceDot() (*LR1Item, error) {
-	if item.GetNextSymbol() == symbols.Epsilon {
-		return nil, fmt.Errorf(`LR1Item production is complete: cannot advance dot`)
-	} else {
-		return NewLR1Item(
-			item.LHS, 
-			item.OriginalRHS, 
-			item.DotPosition+1,
-			item.LookaheadSet,
-		)
+// Get the `string` representation of the core of this item, e.g.
+// `ENTRY -> KEY • : VALUE`. Symbols are separated by spaces so that distinct items
+// never share a name.
+func (item *LR1Item) GetName(grammar *lr1grammar.Grammar) string {
+	names := []string{}
+	rhs := grammar.RuleRHS(item.Rule)
+	for idx, symbol := range rhs {
+		if (idx == item.Dot) {
+			names = append(names, symbols.Dot)
+		}
+		names = append(names, grammar.Symbols.Name(symbol))
 	}
+	if (item.Dot >= len(rhs)) {
+		names = append(names, symbols.Dot)
+	}
+	if (len(rhs) == 0) {
+		names = append(names, symbols.Epsilon)
+	}
+	return grammar.Symbols.Name(grammar.RuleLHS(item.Rule)) + " -> " + strings.Join(names, " ")
 }
 
-// Get the `string` representation of this item.
-func (item *LR1Item) GetName() string {
-	name := item.LHS + "->" + strings.Join(item.RHS, "")
-	return name
-}
\ No newline at end of file
+// Get the names of the lookaheads in sorted order.
+func (item *LR1Item) GetLookaheads(grammar *lr1grammar.Grammar) []string {
+	lookaheads := grammar.Symbols.Names(item.Lookaheads)
+	sort.Strings(lookaheads)
+	return lookaheads
+}
@@ -0,0 +1,121 @@
+package lr1parser
+
+import (
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"io"
+	"strings"
+)
+
+/*
+A callback run when the driver reduces by a production, like a yacc action. It
+receives the values of the RHS symbols - the `*lexer.Token` for terminals and the
+value returned by the nested reduction for non-terminals - and returns the value of
+the LHS (`$$`). Epsilon productions receive no children.
+*/
+type SemanticAction func(children []any) (any, error)
+
+// A set of `SemanticAction`s keyed by production rule ID.
+type SemanticActions struct {
+	grammar *lr1grammar.Grammar
+	actions map[int]SemanticAction
+}
+
+func NewSemanticActions(grammar *lr1grammar.Grammar) *SemanticActions {
+	return &SemanticActions{grammar, make(map[int]SemanticAction)}
+}
+
+// Registers an action for the production `LHS -> RHS`.
+func (sa *SemanticActions) On(LHS string, RHS []string, action SemanticAction) error {
+	ruleId, err := sa.grammar.GetProductionId(LHS, RHS)
+	if err != nil {
+		return fmt.Errorf(`Cannot register action for %s -> %s: %w`, LHS, strings.Join(RHS, " "), err)
+	}
+	sa.actions[ruleId] = action
+	return nil
+}
+
+// Registers an action for a production rule ID as returned by `Grammar.GetProductionId`.
+func (sa *SemanticActions) OnRule(ruleId int, action SemanticAction) error {
+	if _, exists := sa.grammar.ProductionRules[uint(ruleId)]; !exists {
+		return fmt.Errorf(`Cannot register action: rule id %d does not exist`, ruleId)
+	}
+	sa.actions[ruleId] = action
+	return nil
+}
+
+// Runs the registered action of a production. Productions without an action take
+// the value of their first child, like yacc's default `$$ = $1`.
+func (sa *SemanticActions) Reduce(ruleId int, productionRule lr1grammar.ProductionRule, children []any) (any, error) {
+	action, exists := sa.actions[ruleId]
+	if (exists) {
+		return action(children)
+	}
+	if (len(children) == 0) {
+		return nil, nil
+	}
+	return children[0], nil
+}
+
+func (sa *SemanticActions) Shift(token *lexer.Token) any {
+	return token
+}
+
+// The value of the error terminal in actions is the `*SyntaxError` it recovered from.
+func (sa *SemanticActions) Error(syntaxError *SyntaxError, skipped []any) any {
+	return syntaxError
+}
+
+// Raised when a `SemanticAction` returns an error. The position is that of the first
+// token covered by the reduced production, or of the lookahead token for productions
+// that cover no input.
+type ActionError struct {
+	RuleId int
+	ProductionRule lr1grammar.ProductionRule
+	Line uint
+	Col uint
+	Err error
+}
+
+func (err *ActionError) Error() string {
+	return fmt.Sprintf(
+		`Error in action for %s -> %s at %d:%d: %s`,
+		err.ProductionRule.NonTerminal,
+		strings.Join(err.ProductionRule.Production, " "),
+		err.Line,
+		err.Col,
+		err.Err.Error(),
+	)
+}
+
+func (err *ActionError) Unwrap() error {
+	return err.Err
+}
+
+// Parses an input running `actions` on every reduction. Returns the value of the
+// start symbol.
+func (p *Parser) ParseStringWithActions(input string, actions *SemanticActions) (any, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ParseTokensWithActions(*tokens, actions)
+}
+
+func (p *Parser) ParseReaderWithActions(reader io.Reader, actions *SemanticActions) (any, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf(`Error reading input: %w`, err)
+	}
+
+	return p.ParseStringWithActions(string(bytes), actions)
+}
+
+func (p *Parser) ParseTokensWithActions(tokens []*lexer.Token, actions *SemanticActions) (any, error) {
+	if (actions.grammar != p.Grammar) {
+		return nil, fmt.Errorf(`SemanticActions were registered for a different grammar`)
+	}
+	return p.drive(tokens, actions)
+}
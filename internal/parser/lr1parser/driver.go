@@ -0,0 +1,164 @@
+package lr1parser
+
+import (
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parsingtable"
+	"interpreters/internal/symbols"
+)
+
+// Number of tokens to shift after recovering from a syntax error before new syntax
+// errors are reported again, as in yacc.
+const recoveryShifts = 3
+
+// Builds the value of every symbol shifted or reduced by the LR driver.
+type reducer interface {
+	Shift(token *lexer.Token) any
+	Reduce(ruleId int, productionRule lr1grammar.ProductionRule, children []any) (any, error)
+	// Value of the error terminal shifted when recovering from `syntaxError`.
+	// `skipped` holds the values popped from the stack and then the values of the
+	// discarded tokens, in input order.
+	Error(syntaxError *SyntaxError, skipped []any) any
+}
+
+type stackEntry struct {
+	state int
+	value any
+	// first token covered by the entry, `nil` for entries that cover no input
+	start *lexer.Token
+}
+
+/*
+Runs the LR(1) driver over a token stream terminated by an EOF token and returns
+the value of the start symbol.
+
+Grammars with error productions recover from syntax errors (see `recover`): every
+reported `SyntaxError` is collected and the value of the start symbol is returned
+along with `SyntaxErrors` if the input could still be parsed to the end.
+*/
+func (p *Parser) drive(tokens []*lexer.Token, r reducer) (any, error) {
+	if err := CheckTokenStream(tokens); err != nil {
+		return nil, err
+	}
+
+	stack := []stackEntry{{0, nil, nil}}
+	tokenIdx := 0
+	diagnostics := SyntaxErrors{}
+	recovering := 0
+	lastRecoveryIdx := -1
+
+	for {
+		token := tokens[tokenIdx]
+		currState := stack[len(stack) - 1].state
+		action := p.Table.Action(currState, token.Type)
+
+		switch action.ActionVerb() {
+		case lr1parsingtable.SHIFT:
+			stack = append(stack, stackEntry{action.NextState(), r.Shift(token), token})
+			tokenIdx++
+			if (recovering > 0) {
+				recovering--
+			}
+
+		case lr1parsingtable.REDUCE:
+			ruleId := action.ReduceByRule()
+			productionRule := p.Grammar.ProductionRules[uint(ruleId)]
+			length := productionRule.Length()
+
+			popped := stack[len(stack) - length:]
+			stack = stack[:len(stack) - length]
+			children := make([]any, length)
+			var start *lexer.Token
+			for idx, entry := range popped {
+				children[idx] = entry.value
+				if (start == nil) {
+					start = entry.start
+				}
+			}
+
+			value, err := r.Reduce(ruleId, productionRule, children)
+			if err != nil {
+				position := start
+				if (position == nil) {
+					position = token
+				}
+				return nil, &ActionError{ruleId, productionRule, position.Line, position.Col, err}
+			}
+
+			gotoAction := p.Table.Goto(stack[len(stack) - 1].state, productionRule.NonTerminal)
+			if (gotoAction.ActionVerb() != lr1parsingtable.GOTO) {
+				return nil, fmt.Errorf(`Corrupt parsing table: %s`, gotoAction.Message())
+			}
+			stack = append(stack, stackEntry{gotoAction.NextState(), value, start})
+
+		case lr1parsingtable.ACCEPT:
+			// the augmented start production is never reduced: the only entry left
+			// above I_0 holds the value of the original start symbol
+			if (len(diagnostics) > 0) {
+				return stack[1].value, diagnostics
+			}
+			return stack[1].value, nil
+
+		default:
+			syntaxError := NewSyntaxError(p.Grammar.DisplayName, token, p.Table.ExpectedTerminals(currState))
+			if (recovering == 0) {
+				diagnostics = append(diagnostics, syntaxError)
+			}
+
+			var recovered bool
+			stack, tokenIdx, recovered = p.recover(stack, tokens, tokenIdx, tokenIdx == lastRecoveryIdx, r, syntaxError)
+			if (!recovered) {
+				if (len(diagnostics) == 1) {
+					return nil, diagnostics[0]
+				}
+				return nil, diagnostics
+			}
+			lastRecoveryIdx = tokenIdx
+			recovering = recoveryShifts
+		}
+	}
+}
+
+/*
+Panic-mode recovery as in yacc: pops states until one can shift the error terminal,
+shifts it and then discards tokens until one can follow it. Returns the new stack
+and token index, or false if no state can shift the error terminal or the input
+ends first.
+
+`skipToken` discards the current token first: it is set when the driver fails again
+at the token it resumed on, which would otherwise loop forever.
+*/
+func (p *Parser) recover(stack []stackEntry, tokens []*lexer.Token, tokenIdx int, skipToken bool, r reducer, syntaxError *SyntaxError) ([]stackEntry, int, bool) {
+	skipped := []any{}
+	var start *lexer.Token
+
+	for p.Table.Action(stack[len(stack) - 1].state, symbols.Error).ActionVerb() != lr1parsingtable.SHIFT {
+		if (len(stack) == 1) {
+			return stack, tokenIdx, false
+		}
+		entry := stack[len(stack) - 1]
+		stack = stack[:len(stack) - 1]
+		skipped = append([]any{entry.value}, skipped...)
+		if (entry.start != nil) {
+			start = entry.start
+		}
+	}
+	errorState := p.Table.Action(stack[len(stack) - 1].state, symbols.Error).NextState()
+
+	for skipToken || p.Table.Action(errorState, tokens[tokenIdx].Type).ActionVerb() == lr1parsingtable.ERROR {
+		token := tokens[tokenIdx]
+		if (token.Type == symbols.EOF) {
+			return stack, tokenIdx, false
+		}
+		skipped = append(skipped, r.Shift(token))
+		if (start == nil) {
+			start = token
+		}
+		tokenIdx++
+		skipToken = false
+	}
+
+	stack = append(stack, stackEntry{errorState, r.Error(syntaxError, skipped), start})
+	return stack, tokenIdx, true
+}
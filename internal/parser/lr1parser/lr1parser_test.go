@@ -0,0 +1,680 @@
+package lr1parser_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/utilities/arrays"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParser(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	var testCases = []struct{
+		name string
+		input string
+		err string
+	}{
+		{"Parser accepts a single value.", `true`, ""},
+		{"Parser accepts an empty object.", `{}`, ""},
+		{"Parser accepts nested values.", `{ "a": [1, 2, { "b": null }], "c": false }`, ""},
+		{"Parser rejects a trailing comma.", `[1, 2, ]`, "unexpected ']' at 1:8, expected '[', false, null, num_lit, str_lit, true or '{'"},
+		{"Parser rejects a missing colon.", `{ "a" 1 }`, "unexpected '1' at 1:7, expected ':'"},
+		{"Parser rejects unterminated input.", `{ "a": [`, "unexpected end of input at 1:9, expected '[', ']', false, null, num_lit, str_lit, true or '{'"},
+		{"Parser reports lexer errors.", `{ @ }`, "Unrecognized symbol at 1:3"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parser.ParseString(tc.input)
+			if (tc.err == "" && err != nil) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if (tc.err != "" && (err == nil || err.Error() != tc.err)) {
+				t.Errorf("expected error %q, got: %v", tc.err, err)
+			}
+		})
+	}
+
+	_, err = Parser.ParseReader(strings.NewReader(`[ { "a": 1 } ]`))
+	if (err != nil) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	config, err := lr1grammar.ReadGrammarConfigJson("./grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	config.DisplayNames = map[string]string{"str_lit": "string", "num_lit": "number"}
+	Parser, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	_, err = Parser.ParseString("{\n  \"a\": 1,\n  }")
+	var syntaxError *lr1parser.SyntaxError
+	if (!errors.As(err, &syntaxError)) {
+		t.Fatalf("expected a SyntaxError, got: %v", err)
+	}
+	if (syntaxError.Token.Value != "}" || syntaxError.Line != 3 || syntaxError.Col != 3) {
+		t.Errorf("unexpected token or position: %v at %d:%d", syntaxError.Token, syntaxError.Line, syntaxError.Col)
+	}
+	if diff := deep.Equal(syntaxError.Expected, []string{"num_lit", "str_lit"}); diff != nil {
+		t.Error(diff)
+	}
+	if (err.Error() != "unexpected '}' at 3:3, expected number or string") {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	// display names survive serialization
+	var buffer bytes.Buffer
+	if err := Parser.Save(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := lr1parser.LoadParser(&buffer)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	_, err = loaded.ParseString(`{ "a" 1`)
+	if (err == nil || err.Error() != "unexpected '1' at 1:7, expected ':'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = loaded.ParseString(`[1`)
+	if (err == nil || err.Error() != "unexpected end of input at 1:3, expected ',' or ']'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = loaded.ParseString(`{ "a": }`)
+	if (err == nil || err.Error() != "unexpected '}' at 1:8, expected '[', false, null, number, string, true or '{'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParserRecoversFromErrors(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			GenericTokens: lexer.TokenConfigJsonArr{
+				{Type: "num_lit", Pattern: `(\d+)`},
+				{Type: "ident", Pattern: `([a-z]\w*)`},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"STMTS": lr1grammar.NewProductionsJson([]string{"STMT"}, []string{"STMTS", "STMT"}),
+			"STMT": lr1grammar.NewProductionsJson(
+				[]string{"ident", "'='", "num_lit", "';'"},
+				[]string{"error", "';'"},
+			),
+		},
+		StartSymbol: "STMTS",
+	}
+	Parser, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	tree, err := Parser.ParseString("a = 1;\nb = = 2;\nc = 3;\nd 4; e = 5;")
+	var syntaxErrors lr1parser.SyntaxErrors
+	if (!errors.As(err, &syntaxErrors)) {
+		t.Fatalf("expected SyntaxErrors, got: %v", err)
+	}
+	expectedMessage := "unexpected '=' at 2:5, expected num_lit\nunexpected '4' at 4:3, expected '='"
+	if (err.Error() != expectedMessage) {
+		t.Errorf("unexpected diagnostics:\n%v", err)
+	}
+	if (tree == nil) {
+		t.Fatal("expected a partial tree")
+	}
+
+	statements := cst.Find(tree, func (element cst.Element) bool { return element.Symbol() == "STMT" })
+	errorNodes := cst.Find(tree, func (element cst.Element) bool {
+		node, isNode := element.(*cst.Node)
+		return isNode && node.IsError()
+	})
+	if (len(statements) != 5 || len(errorNodes) != 2) {
+		t.Fatalf("expected 5 statements and 2 error nodes, got %d and %d", len(statements), len(errorNodes))
+	}
+	skipped := arrays.Map(cst.Leaves(errorNodes[0]), func (leaf *cst.Leaf) string { return leaf.Token.Value })
+	if diff := deep.Equal(skipped, []string{"b", "=", "=", "2"}); diff != nil {
+		t.Error(diff)
+	}
+
+	// recovery fails when the input ends before a token can follow the error
+	tree, err = Parser.ParseString("a = 1; b =")
+	var syntaxError *lr1parser.SyntaxError
+	if (tree != nil || !errors.As(err, &syntaxError) || err.Error() != "unexpected end of input at 1:11, expected num_lit") {
+		t.Errorf("unexpected result: %v, %v", tree, err)
+	}
+}
+
+func TestParserRepairsErrors(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	var testCases = []struct{
+		name string
+		input string
+		repairs []string
+	}{
+		{"Valid input needs no repair.", `[1, 2]`, []string{}},
+		{"Missing comma is inserted.", `[1 2]`, []string{"inserted missing ',' at 1:4"}},
+		{"Extra bracket is deleted.", `[1, 2]]`, []string{"deleted unexpected ']' at 1:7"}},
+		{"Wrong separator is replaced.", `{ "a" , 1 }`, []string{"replaced ',' with ':' at 1:7"}},
+		{"Every error is repaired.", `{ "a" 1, "b": 2 "c": 3 }`, []string{
+			"inserted missing ':' at 1:7",
+			"inserted missing ',' at 1:17",
+		}},
+		{"Several edits repair one error.", `{ "a" }`, []string{
+			"inserted missing ':' at 1:7",
+			"inserted missing false at 1:7",
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, repairs, err := Parser.ParseStringWithRepair(tc.input)
+			if (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (tree == nil) {
+				t.Fatal("expected a tree")
+			}
+			messages := arrays.Map(repairs, func (repair lr1parser.Repair) string { return repair.String() })
+			if diff := deep.Equal(messages, tc.repairs); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+
+	tree, _, _ := Parser.ParseStringWithRepair(`[1 2]`)
+	values := arrays.Map(cst.Leaves(tree), func (leaf *cst.Leaf) string { return leaf.Token.Value })
+	if diff := deep.Equal(values, []string{"[", "1", ",", "2", "]"}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestParserValidatesTerminals(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "a", Pattern: "(a)"},
+				{Type: "c", Pattern: "(c)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"a", "b"}),
+		},
+		StartSymbol: "S",
+	}
+
+	_, err := lr1parser.NewParser(config)
+	expected := "Grammar terminals do not match token definitions: " +
+		"symbol: b has no token definition; token: c is not used by any production"
+	if (err == nil || err.Error() != expected) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParserReportsConflicts(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			SymbolTokens: lexer.TokenConfigJsonArr{
+				{Type: "+", Pattern: `(\+)`},
+				{Type: "n", Pattern: "(n)"},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"E": lr1grammar.NewProductionsJson([]string{"E", "+", "E"}, []string{"n"}),
+		},
+		StartSymbol: "E",
+	}
+
+	_, err := lr1parser.NewParser(config)
+	if (err == nil || !strings.HasPrefix(err.Error(), "Grammar is not LR(1): shift/reduce conflict")) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParserWithLiteralTerminals(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		Terminals: lexer.LexerConfigJson{
+			GenericTokens: lexer.TokenConfigJsonArr{
+				{Type: "num_lit", Pattern: `(-?\d+)`},
+				{Type: "ident", Pattern: `([a-z]\w*)`},
+			},
+		},
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"LIST": lr1grammar.NewProductionsJson([]string{"'['", "ITEMS", "']'"}, []string{`"null"`}),
+			"ITEMS": lr1grammar.NewProductionsJson([]string{"ITEM"}, []string{"ITEMS", "','", "ITEM"}),
+			"ITEM": lr1grammar.NewProductionsJson([]string{"num_lit"}, []string{"ident"}, []string{"LIST"}),
+		},
+		StartSymbol: "LIST",
+	}
+
+	Parser, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	for _, input := range []string{`[1, nullable, [2], null]`, `null`} {
+		if _, err := Parser.ParseString(input); err != nil {
+			t.Errorf("%s: unexpected error: %v", input, err)
+		}
+	}
+	if _, err := Parser.ParseString(`[1 2]`); err == nil || err.Error() != "unexpected '2' at 1:4, expected ',' or ']'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !Parser.Grammar.Terminals.Has("[") || !Parser.Grammar.Terminals.Has("null") {
+		t.Error("literal terminals were not registered")
+	}
+}
+
+func TestParserRejectsLiteralNamingNonTerminal(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"LIST": lr1grammar.NewProductionsJson([]string{"'['", "'ITEM'", "']'"}),
+			"ITEM": lr1grammar.NewProductionsJson([]string{"'x'"}),
+		},
+		StartSymbol: "LIST",
+	}
+
+	_, err := lr1parser.NewParser(config)
+	if (err == nil || err.Error() != "Literal terminal 'ITEM' clashes with the non-terminal: ITEM") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParserWithQuotedQuoteLiteral(t *testing.T) {
+	config := lr1grammar.GrammarConfigJson{
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{`'"a"'`, "';'"}),
+		},
+		StartSymbol: "S",
+	}
+
+	Parser, err := lr1parser.NewParser(config)
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+	if _, err := Parser.ParseString(`"a";`); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !Parser.Grammar.Terminals.Has(`"a"`) {
+		t.Error(`expected the terminal "a" to keep its quotes`)
+	}
+}
+
+func TestParserBuildsConcreteSyntaxTree(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	tree, err := Parser.ParseString("{\n  \"a\": [1]\n}")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	// VALUE -> OBJECT -> { ENTRIES? } where ENTRIES? -> ENTRY ENTRY? and ENTRY? -> EPSILON
+	object := tree.Children[0].(*cst.Node)
+	entries := object.Children[1].(*cst.Node)
+	emptyEntry := entries.Children[1].(*cst.Node)
+	if (tree.NonTerminal != "VALUE" || object.NonTerminal != "OBJECT" || entries.NonTerminal != "ENTRIES?") {
+		t.Errorf("unexpected tree shape: %s > %s > %s", tree.NonTerminal, object.NonTerminal, entries.NonTerminal)
+	}
+	if (len(emptyEntry.Children) != 0 || !emptyEntry.Span().IsEmpty()) {
+		t.Errorf("expected an empty epsilon node, got: %+v", emptyEntry)
+	}
+
+	ruleId, _ := Parser.Grammar.GetProductionId("OBJECT", []string{"{", "ENTRIES?", "}"})
+	if (object.RuleId != ruleId) {
+		t.Errorf("expected rule id %d, got %d", ruleId, object.RuleId)
+	}
+
+	expectedSpan := cst.Span{Start: cst.Position{Line: 1, Col: 1}, End: cst.Position{Line: 3, Col: 2}}
+	if diff := deep.Equal(tree.Span(), expectedSpan); diff != nil {
+		t.Error(diff)
+	}
+
+	values := arrays.Map(cst.Leaves(tree), func (leaf *cst.Leaf) string { return leaf.Token.Value })
+	if diff := deep.Equal(values, []string{"{", `"a"`, ":", "[", "1", "]", "}"}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSemanticActions(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	// builds Go values from JSON, entries and elements are accumulated as slices
+	type entry struct {
+		key string
+		value any
+	}
+	token := func (child any) *lexer.Token { return child.(*lexer.Token) }
+	prepend := func (item any, rest any) []any {
+		if (rest == nil) {
+			return []any{item}
+		}
+		return append([]any{item}, rest.([]any)...)
+	}
+
+	actions := lr1parser.NewSemanticActions(Parser.Grammar)
+	registrations := []struct{
+		LHS string
+		RHS []string
+		action lr1parser.SemanticAction
+	}{
+		{"VALUE", []string{"num_lit"}, func (children []any) (any, error) {
+			return strconv.ParseFloat(token(children[0]).Value, 64)
+		}},
+		{"VALUE", []string{"str_lit"}, func (children []any) (any, error) {
+			return strconv.Unquote(token(children[0]).Value)
+		}},
+		{"VALUE", []string{"true"}, func (children []any) (any, error) { return true, nil }},
+		{"VALUE", []string{"null"}, func (children []any) (any, error) { return nil, nil }},
+		{"KEY", []string{"str_lit"}, func (children []any) (any, error) {
+			return strconv.Unquote(token(children[0]).Value)
+		}},
+		{"KEY", []string{"num_lit"}, func (children []any) (any, error) {
+			return nil, fmt.Errorf("numeric keys are not supported")
+		}},
+		{"ENTRY", []string{"KEY", ":", "VALUE"}, func (children []any) (any, error) {
+			return entry{children[0].(string), children[2]}, nil
+		}},
+		{"ENTRIES?", []string{"ENTRY", "ENTRY?"}, func (children []any) (any, error) {
+			return prepend(children[0], children[1]), nil
+		}},
+		{"ENTRY?", []string{",", "ENTRY", "ENTRY?"}, func (children []any) (any, error) {
+			return prepend(children[1], children[2]), nil
+		}},
+		{"OBJECT", []string{"{", "ENTRIES?", "}"}, func (children []any) (any, error) {
+			object := map[string]any{}
+			entries, _ := children[1].([]any)
+			for _, item := range entries {
+				object[item.(entry).key] = item.(entry).value
+			}
+			return object, nil
+		}},
+		{"ELEMENTS?", []string{"VALUE", "ELEMENT?"}, func (children []any) (any, error) {
+			return prepend(children[0], children[1]), nil
+		}},
+		{"ELEMENT?", []string{",", "VALUE", "ELEMENT?"}, func (children []any) (any, error) {
+			return prepend(children[1], children[2]), nil
+		}},
+		{"ARRAY", []string{"[", "ELEMENTS?", "]"}, func (children []any) (any, error) {
+			elements, _ := children[1].([]any)
+			return append([]any{}, elements...), nil
+		}},
+	}
+	for _, registration := range registrations {
+		if err := actions.On(registration.LHS, registration.RHS, registration.action); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// VALUE -> OBJECT and VALUE -> ARRAY use the default action: $$ = $1
+	value, err := Parser.ParseStringWithActions(`{ "a": [1, "two", null], "b": { "c": true } }`, actions)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"a": []any{1.0, "two", nil},
+		"b": map[string]any{"c": true},
+	}
+	if diff := deep.Equal(value, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	_, err = Parser.ParseStringWithActions("{\n  \"a\": 1,\n  2: 3 }", actions)
+	var actionError *lr1parser.ActionError
+	if (!errors.As(err, &actionError)) {
+		t.Fatalf("expected an ActionError, got: %v", err)
+	}
+	if (err.Error() != "Error in action for KEY -> num_lit at 3:3: numeric keys are not supported") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := actions.On("KEY", []string{"true"}, nil); err == nil {
+		t.Error("expected registering an unknown production to fail")
+	}
+}
+
+func TestParserShapesTree(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./shaped-grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	tree, err := Parser.ParseString(`{ "a": [1, null], "b": {} }`)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	// renders nodes by kind and leaves by value
+	var render func (element cst.Element) string
+	render = func (element cst.Element) string {
+		switch element := element.(type) {
+		case *cst.Node:
+			children := arrays.Map(element.Children, render)
+			return element.Kind + "(" + strings.Join(children, " ") + ")"
+		case *cst.Leaf:
+			return element.Token.Value
+		}
+		return ""
+	}
+
+	expected := `Object(Entry("a" Array(Literal(1) Literal(null))) Entry("b" Object()))`
+	if (render(tree) != expected) {
+		t.Errorf("unexpected tree: %s", render(tree))
+	}
+
+	entry := tree.Children[1].(*cst.Node)
+	if (render(entry.Field("key")) != `"b"` || render(entry.Field("value")) != "Object()") {
+		t.Errorf("unexpected fields: %v", entry.Fields)
+	}
+	if (entry.NonTerminal != "ENTRY" || tree.NonTerminal != "OBJECT") {
+		t.Errorf("renamed nodes should keep their non-terminal")
+	}
+
+	// dropped delimiters still count towards the span of their node
+	span := func (startCol uint, endCol uint) cst.Span {
+		return cst.Span{Start: cst.Position{Line: 1, Col: startCol}, End: cst.Position{Line: 1, Col: endCol}}
+	}
+	if diff := deep.Equal(tree.Span(), span(1, 28)); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(entry.Field("value").Span(), span(24, 26)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestParserValidatesAnnotations(t *testing.T) {
+	entry := lr1grammar.NewProductionJson("'a'", "'b'")
+	entry.Drop = []string{"'b'", "c"}
+	entry.Fields = map[string]int{"first": 0, "second": 1}
+	config := lr1grammar.GrammarConfigJson{
+		NonTerminals: map[string][]lr1grammar.ProductionJson{"S": {entry}},
+		StartSymbol: "S",
+	}
+
+	_, err := lr1parser.NewParser(config)
+	expected := "Invalid production annotations: " +
+		"S -> a b: dropped symbol c is not in the production; " +
+		"S -> a b: field second refers to a dropped symbol"
+	if (err == nil || err.Error() != expected) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSaveAndLoadParser(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./shaped-grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	var buffer bytes.Buffer
+	if err := Parser.Save(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := lr1parser.LoadParser(&buffer)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	if (loaded.SourceHash() != Parser.SourceHash() || loaded.Table.TextReport() != Parser.Table.TextReport()) {
+		t.Error("loaded parser differs from the saved parser")
+	}
+
+	input := `{ "a": [1, null], "b": { "c": true } }`
+	expected, _ := Parser.ParseString(input)
+	tree, err := loaded.ParseString(input)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (cst.ToSExpr(tree) != cst.ToSExpr(expected)) {
+		t.Errorf("unexpected tree from loaded parser:\n%s", cst.ToSExpr(tree))
+	}
+
+	_, err = lr1parser.LoadParser(strings.NewReader(`{"version": 0}`))
+	if (!errors.Is(err, lr1parser.ErrIncompatibleVersion)) {
+		t.Errorf("expected ErrIncompatibleVersion, got: %v", err)
+	}
+}
+
+func TestLoadParserRejectsCorruptedFiles(t *testing.T) {
+	Parser, err := lr1parser.NewParser(lr1grammar.GrammarConfigJson{
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"'a'"}),
+		},
+		StartSymbol: "S",
+	})
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	var testCases = []struct{
+		name string
+		corrupt func (serialized map[string]any)
+		message string
+	}{
+		{
+			"Negative shift targets are rejected.",
+			func (serialized map[string]any) {
+				serialized["states"].([]any)[0].(map[string]any)["a"] = "s-1"
+			},
+			"state 0 refers to unknown state: -1",
+		},
+		{
+			"Reserved terminals are rejected.",
+			func (serialized map[string]any) {
+				serialized["terminals"] = append(serialized["terminals"].([]any), "$")
+			},
+			"Grammar cannot use the reserved symbol: $",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := Parser.Save(&buffer); err != nil {
+				t.Fatal(err)
+			}
+			var serialized map[string]any
+			if err := json.Unmarshal(buffer.Bytes(), &serialized); err != nil {
+				t.Fatal(err)
+			}
+			tc.corrupt(serialized)
+			corrupted, err := json.Marshal(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = lr1parser.LoadParser(bytes.NewReader(corrupted))
+			if (err == nil || err.Error() != tc.message) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParserCacheDetectsStaleness(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "grammar-config.json")
+	cachePath := filepath.Join(dir, "grammar-config.parser.json")
+
+	writeConfig := func (productions string) {
+		config := `{ "nonTerminals": { "S": [` + productions + `] }, "startSymbol": "S" }`
+		if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig(`["'a'"]`)
+	first, err := lr1parser.NewParserFromJsonConfigCached(configPath, cachePath)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (first.Table.Automaton == nil) {
+		t.Error("expected the first parser to be built from scratch")
+	}
+
+	cached, err := lr1parser.NewParserFromJsonConfigCached(configPath, cachePath)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (cached.Table.Automaton != nil) {
+		t.Error("expected the second parser to be loaded from the cache")
+	}
+
+	writeConfig(`["'b'"]`)
+	rebuilt, err := lr1parser.NewParserFromJsonConfigCached(configPath, cachePath)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	if (rebuilt.Table.Automaton == nil || rebuilt.SourceHash() == first.SourceHash()) {
+		t.Error("expected a stale cache to be rebuilt")
+	}
+	if _, err := rebuilt.ParseString("b"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParserRejectsTokensWithoutEOF(t *testing.T) {
+	Parser, err := lr1parser.NewParserFromJsonConfig("./grammar-config.json")
+	if (err != nil) {
+		t.Fatal("Failed to initialize parser: ", err.Error())
+	}
+
+	tokens := *Parser.Lexer.MustTokenize("[true]")
+	for _, input := range [][]*lexer.Token{{}, tokens[:len(tokens) - 1]} {
+		if _, err := Parser.ParseTokens(input); !errors.Is(err, lr1parser.ErrMissingEOF) {
+			t.Errorf("ParseTokens: expected ErrMissingEOF for %d tokens, got: %v", len(input), err)
+		}
+		if _, err := Parser.ParseTokensWithActions(input, lr1parser.NewSemanticActions(Parser.Grammar)); !errors.Is(err, lr1parser.ErrMissingEOF) {
+			t.Errorf("ParseTokensWithActions: expected ErrMissingEOF for %d tokens, got: %v", len(input), err)
+		}
+		if _, _, err := Parser.ParseTokensWithRepair(input); !errors.Is(err, lr1parser.ErrMissingEOF) {
+			t.Errorf("ParseTokensWithRepair: expected ErrMissingEOF for %d tokens, got: %v", len(input), err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package lr1parser
+
+import (
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parsingtable"
+	"io"
+)
+
+/*
+A `Parser` bundles the lexer, the augmented grammar and the LR(1) parsing table
+built from a single `GrammarConfigJson`. A `Parser` is immutable once built and can
+be shared across goroutines.
+*/
+type Parser struct {
+	Lexer *lexer.Lexer
+	Grammar *lr1grammar.Grammar
+	Table *lr1parsingtable.ParsingTable
+
+	// lexer definition the `Lexer` was built from
+	lexerConfig lexer.LexerConfigJson
+	// hash of the `GrammarConfigJson` the parser was built from
+	sourceHash string
+}
+
+func NewParser(config lr1grammar.GrammarConfigJson) (*Parser, error) {
+	sourceHash, err := HashGrammarConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// resolved up front as well so that `Save` writes the literal tokens
+	config, err = lr1grammar.ResolveLiteralTerminals(config)
+	if err != nil {
+		return nil, err
+	}
+
+	lex, grammar, err := lr1grammar.NewLexerAndGrammar(config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := lr1parsingtable.NewLR1ParsingTable(grammar)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{lex, grammar, table, config.Terminals, sourceHash}, nil
+}
+
+func NewParserFromJsonConfig(path string) (*Parser, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewParser(config)
+}
+
+// Parses an input into a concrete syntax tree rooted at the start symbol.
+func (p *Parser) ParseString(input string) (*cst.Node, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ParseTokens(*tokens)
+}
+
+func (p *Parser) ParseReader(reader io.Reader) (*cst.Node, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf(`Error reading input: %w`, err)
+	}
+
+	return p.ParseString(string(bytes))
+}
+
+/*
+Runs the LR(1) driver over a token stream terminated by an EOF token, returns
+`ErrMissingEOF` otherwise.
+
+If the grammar has error productions and the parser recovered from syntax errors,
+the partial tree is returned together with the `SyntaxErrors`. Input skipped by the
+recovery is kept in error nodes, see `cst.NewErrorNode`.
+*/
+func (p *Parser) ParseTokens(tokens []*lexer.Token) (*cst.Node, error) {
+	builder := TreeBuilder{}
+	tree, err := p.drive(tokens, builder)
+	if (tree == nil) {
+		return nil, err
+	}
+	return builder.Root(tree), err
+}
@@ -0,0 +1,249 @@
+package lr1parser
+
+import (
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1parsingtable"
+	"interpreters/internal/symbols"
+	"slices"
+)
+
+// Largest number of token edits tried to repair a single syntax error.
+const maxRepairCost = 3
+
+// Number of tokens that must parse after a repair for it to be accepted.
+const repairCheckTokens = 3
+
+type RepairKind string
+
+const (
+	InsertToken 	RepairKind = "insert"
+	DeleteToken 	RepairKind = "delete"
+	ReplaceToken 	RepairKind = "replace"
+)
+
+/*
+A token edit applied to the input to repair a syntax error. `Token` is the token
+the insertion is placed before, or the deleted or replaced token. `Terminal` is the
+inserted or substituted terminal, empty for deletions.
+*/
+type Repair struct {
+	Kind RepairKind
+	Token *lexer.Token
+	Terminal string
+
+	parser *Parser
+}
+
+// Describes the repair, e.g. `inserted missing ',' at 1:4`.
+func (r Repair) String() string {
+	switch r.Kind {
+	case InsertToken:
+		return fmt.Sprintf(`inserted missing %s at %d:%d`, r.parser.Grammar.DisplayName(r.Terminal), r.Token.Line, r.Token.Col)
+	case DeleteToken:
+		return fmt.Sprintf(`deleted unexpected '%s' at %d:%d`, r.Token.Value, r.Token.Line, r.Token.Col)
+	}
+	return fmt.Sprintf(`replaced '%s' with %s at %d:%d`, r.Token.Value, r.parser.Grammar.DisplayName(r.Terminal), r.Token.Line, r.Token.Col)
+}
+
+// Same as `ParseTokensWithRepair` for an input string.
+func (p *Parser) ParseStringWithRepair(input string) (*cst.Node, []Repair, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p.ParseTokensWithRepair(*tokens)
+}
+
+/*
+Parses a token stream, repairing every syntax error with the cheapest sequence of
+token insertions, deletions and replacements at the error that lets the following
+tokens parse (in the spirit of Burke and Fisher). Candidate terminals come from the
+ACTION row of the failing state. Returns the tree of the repaired input and the
+repairs applied, in input order.
+
+`ErrMissingEOF` is returned if the stream has no EOF token. A `SyntaxError` is
+returned if no repair of at most `maxRepairCost` edits is found.
+*/
+func (p *Parser) ParseTokensWithRepair(tokens []*lexer.Token) (*cst.Node, []Repair, error) {
+	if err := CheckTokenStream(tokens); err != nil {
+		return nil, nil, err
+	}
+
+	tokens = slices.Clone(tokens)
+	repairs := []Repair{}
+	stack := []int{0}
+
+	for tokenIdx := 0; ; {
+		token := tokens[tokenIdx]
+		action := p.Table.Action(stack[len(stack) - 1], token.Type)
+
+		if (action.ActionVerb() == lr1parsingtable.ERROR) {
+			found := p.findRepair(stack, tokens, tokenIdx)
+			if (found == nil) {
+				return nil, repairs, NewSyntaxError(p.Grammar.DisplayName, token, p.Table.ExpectedTerminals(stack[len(stack) - 1]))
+			}
+			tokens = p.applyRepairs(tokens, tokenIdx, found)
+			repairs = append(repairs, found...)
+			continue
+		}
+		if (action.ActionVerb() == lr1parsingtable.ACCEPT) {
+			break
+		}
+
+		stack, _ = p.step(stack, action)
+		if (action.ActionVerb() == lr1parsingtable.SHIFT) {
+			tokenIdx++
+		}
+	}
+
+	tree, err := p.ParseTokens(tokens)
+	return tree, repairs, err
+}
+
+// Applies a SHIFT or REDUCE action to a stack of states in place. Returns false for
+// other actions.
+func (p *Parser) step(stack []int, action lr1parsingtable.ParserAction) ([]int, bool) {
+	switch action.ActionVerb() {
+	case lr1parsingtable.SHIFT:
+		return append(stack, action.NextState()), true
+	case lr1parsingtable.REDUCE:
+		productionRule := p.Grammar.ProductionRules[uint(action.ReduceByRule())]
+		stack = stack[:len(stack) - productionRule.Length()]
+		gotoAction := p.Table.Goto(stack[len(stack) - 1], productionRule.NonTerminal)
+		return append(stack, gotoAction.NextState()), gotoAction.ActionVerb() == lr1parsingtable.GOTO
+	}
+	return stack, false
+}
+
+// Runs the parser over `terminals` without building values. Returns the resulting
+// stack, the number of terminals shifted and whether the input was accepted. The
+// given stack is left untouched.
+func (p *Parser) simulate(stack []int, terminals []string) ([]int, int, bool) {
+	stack = slices.Clone(stack)
+	for shifted, terminal := range terminals {
+		for {
+			action := p.Table.Action(stack[len(stack) - 1], terminal)
+			if (action.ActionVerb() == lr1parsingtable.ACCEPT) {
+				return stack, shifted, true
+			}
+			var ok bool
+			if stack, ok = p.step(stack, action); !ok {
+				return stack, shifted, false
+			}
+			if (action.ActionVerb() == lr1parsingtable.SHIFT) {
+				break
+			}
+		}
+	}
+	return stack, len(terminals), false
+}
+
+// A partially repaired parser configuration: the stack after the repairs so far and
+// the index of the next original token.
+type repairCandidate struct {
+	stack []int
+	tokenIdx int
+	repairs []Repair
+}
+
+// Searches repairs breadth first by number of edits. Candidates of equal cost are
+// tried in a fixed order: insertions, replacements and then deletion, terminals in
+// column order. Returns `nil` if there is no repair of at most `maxRepairCost` edits.
+func (p *Parser) findRepair(stack []int, tokens []*lexer.Token, tokenIdx int) []Repair {
+	frontier := []repairCandidate{{stack, tokenIdx, nil}}
+	for cost := 1; cost <= maxRepairCost; cost++ {
+		next := []repairCandidate{}
+		for _, candidate := range frontier {
+			for _, expanded := range p.expandRepair(candidate, tokens) {
+				if (p.repairSucceeds(expanded, tokens)) {
+					return expanded.repairs
+				}
+				next = append(next, expanded)
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
+
+// All candidates one edit away from `candidate`.
+func (p *Parser) expandRepair(candidate repairCandidate, tokens []*lexer.Token) []repairCandidate {
+	token := tokens[candidate.tokenIdx]
+	// input cannot be truncated nor extended past its end
+	expected := slices.DeleteFunc(p.Table.ExpectedTerminals(candidate.stack[len(candidate.stack) - 1]), func (terminal string) bool {
+		return terminal == symbols.EOF
+	})
+	expanded := []repairCandidate{}
+	withRepair := func (stack []int, tokenIdx int, repair Repair) repairCandidate {
+		repairs := append(slices.Clone(candidate.repairs), repair)
+		return repairCandidate{stack, tokenIdx, repairs}
+	}
+
+	for _, terminal := range expected {
+		if stack, shifted, _ := p.simulate(candidate.stack, []string{terminal}); shifted == 1 {
+			expanded = append(expanded, withRepair(stack, candidate.tokenIdx, Repair{InsertToken, token, terminal, p}))
+		}
+	}
+	if (token.Type == symbols.EOF) {
+		return expanded
+	}
+	for _, terminal := range expected {
+		if (terminal == token.Type) {
+			continue
+		}
+		if stack, shifted, _ := p.simulate(candidate.stack, []string{terminal}); shifted == 1 {
+			expanded = append(expanded, withRepair(stack, candidate.tokenIdx + 1, Repair{ReplaceToken, token, terminal, p}))
+		}
+	}
+	return append(expanded, withRepair(candidate.stack, candidate.tokenIdx + 1, Repair{DeleteToken, token, "", p}))
+}
+
+// Whether the next `repairCheckTokens` original tokens parse after the repairs of
+// `candidate`, or the input is accepted before.
+func (p *Parser) repairSucceeds(candidate repairCandidate, tokens []*lexer.Token) bool {
+	terminals := []string{}
+	for _, token := range tokens[candidate.tokenIdx:] {
+		if (len(terminals) == repairCheckTokens) {
+			break
+		}
+		terminals = append(terminals, token.Type)
+	}
+	_, shifted, accepted := p.simulate(candidate.stack, terminals)
+	return accepted || shifted == len(terminals)
+}
+
+// Applies repairs found at `tokenIdx` to a token stream. Inserted and substituted
+// tokens take the position of the token they replace or precede.
+func (p *Parser) applyRepairs(tokens []*lexer.Token, tokenIdx int, repairs []Repair) []*lexer.Token {
+	for _, repair := range repairs {
+		switch repair.Kind {
+		case InsertToken:
+			tokens = slices.Insert(tokens, tokenIdx, p.syntheticToken(repair.Terminal, repair.Token))
+			tokenIdx++
+		case ReplaceToken:
+			tokens[tokenIdx] = p.syntheticToken(repair.Terminal, repair.Token)
+			tokenIdx++
+		case DeleteToken:
+			tokens = slices.Delete(tokens, tokenIdx, tokenIdx + 1)
+		}
+	}
+	return tokens
+}
+
+// Builds a token for a terminal missing from the input. Its value is the terminal
+// itself if that is a valid lexeme of the terminal (e.g. `,`) and empty otherwise.
+func (p *Parser) syntheticToken(terminal string, position *lexer.Token) *lexer.Token {
+	value := ""
+	symbolTokens, keywordTokens, genericTokens := p.Lexer.TokenGroups()
+	for _, group := range [][]*lexer.TokenConfig{symbolTokens, keywordTokens, genericTokens} {
+		for _, tokenConfig := range group {
+			if (tokenConfig.Type == terminal && tokenConfig.MatchesExactly(terminal)) {
+				value = terminal
+			}
+		}
+	}
+	return &lexer.Token{Type: terminal, Value: value, Line: position.Line, Col: position.Col}
+}
@@ -0,0 +1,174 @@
+package lr1parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parsingtable"
+	"interpreters/internal/symbols"
+	"io"
+	"os"
+	"sort"
+)
+
+// Version of the serialized parser format. Bump whenever the format or the meaning
+// of its contents changes.
+const SerializedParserVersion = 1
+
+var ErrIncompatibleVersion = errors.New(`serialized parser has an incompatible version`)
+
+type serializedRule struct {
+	NonTerminal string `json:"nonTerminal"`
+	Production []string `json:"production"`
+	Annotations *lr1grammar.ProductionAnnotations `json:"annotations,omitempty"`
+}
+
+// Everything needed to restore a `Parser` without recomputing FIRST sets or closures.
+type serializedParser struct {
+	Version int `json:"version"`
+	SourceHash string `json:"sourceHash"`
+	Lexer lexer.LexerConfigJson `json:"lexer"`
+	Terminals []string `json:"terminals"`
+	NonTerminals []string `json:"nonTerminals"`
+	StartSymbol string `json:"startSymbol"`
+	Rules []serializedRule `json:"rules"`
+	DisplayNames map[string]string `json:"displayNames,omitempty"`
+	// one map of symbol -> encoded action per state
+	States []map[string]string `json:"states"`
+}
+
+// Content hash of a `GrammarConfigJson`, used to detect stale serialized parsers.
+func HashGrammarConfig(config lr1grammar.GrammarConfigJson) (string, error) {
+	// map keys are marshalled in sorted order, making the encoding canonical
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bytes)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Hash of the `GrammarConfigJson` the parser was built from.
+func (p *Parser) SourceHash() string {
+	return p.sourceHash
+}
+
+// Writes the parser (lexer definition, grammar rules and parsing table) as JSON.
+func (p *Parser) Save(writer io.Writer) error {
+	serialized := serializedParser{
+		Version: SerializedParserVersion,
+		SourceHash: p.sourceHash,
+		Lexer: p.lexerConfig,
+		StartSymbol: p.Grammar.StartSymbol,
+		DisplayNames: p.Grammar.DisplayNames,
+		States: p.Table.EncodeRows(),
+	}
+
+	for _, terminal := range p.Grammar.Terminals.GetItems() {
+		// implicit terminals are added back by `NewGrammarFromRules`
+		if (terminal != symbols.Epsilon && terminal != symbols.EOF && terminal != symbols.Error) {
+			serialized.Terminals = append(serialized.Terminals, terminal)
+		}
+	}
+	sort.Strings(serialized.Terminals)
+	serialized.NonTerminals = p.Grammar.NonTerminals.GetItems()
+	sort.Strings(serialized.NonTerminals)
+
+	for ruleId := 0; ruleId < len(p.Grammar.ProductionRules); ruleId++ {
+		rule := p.Grammar.ProductionRules[uint(ruleId)]
+		serializedRule := serializedRule{rule.NonTerminal, rule.Production, nil}
+		if (!rule.Annotations.IsEmpty()) {
+			serializedRule.Annotations = &rule.Annotations
+		}
+		serialized.Rules = append(serialized.Rules, serializedRule)
+	}
+
+	return json.NewEncoder(writer).Encode(serialized)
+}
+
+// Restores a parser written by `Parser.Save`. The restored parsing table has no
+// `Automaton`.
+func LoadParser(reader io.Reader) (*Parser, error) {
+	var serialized serializedParser
+	if err := json.NewDecoder(reader).Decode(&serialized); err != nil {
+		return nil, fmt.Errorf(`Error unmarshalling serialized parser: %w`, err)
+	}
+	if (serialized.Version != SerializedParserVersion) {
+		return nil, fmt.Errorf(`%w: %d (expected %d)`, ErrIncompatibleVersion, serialized.Version, SerializedParserVersion)
+	}
+
+	lex, err := lexer.CreateLexer(serialized.Lexer)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]lr1grammar.ProductionRule, len(serialized.Rules))
+	for idx, rule := range serialized.Rules {
+		rules[idx] = lr1grammar.ProductionRule{NonTerminal: rule.NonTerminal, Production: rule.Production}
+		if (rule.Annotations != nil) {
+			rules[idx].Annotations = *rule.Annotations
+		}
+	}
+	grammar, err := lr1grammar.NewGrammarFromRules(
+		serialized.Terminals,
+		serialized.NonTerminals,
+		serialized.StartSymbol,
+		rules,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for terminal, displayName := range serialized.DisplayNames {
+		grammar.DisplayNames[terminal] = displayName
+	}
+
+	table, err := lr1parsingtable.NewParsingTableFromRows(grammar, serialized.States)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{lex, grammar, table, serialized.Lexer, serialized.SourceHash}, nil
+}
+
+/*
+Builds a parser from a `GrammarConfigJson` file, reusing the parser serialized at
+`cachePath` if it was built from identical grammar config. Otherwise the parser is
+built from scratch and written to `cachePath` for the next run.
+*/
+func NewParserFromJsonConfigCached(configPath string, cachePath string) (*Parser, error) {
+	config, err := lr1grammar.ReadGrammarConfigJson(configPath)
+	if err != nil {
+		return nil, err
+	}
+	sourceHash, err := HashGrammarConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheFile, err := os.Open(cachePath); err == nil {
+		parser, err := LoadParser(cacheFile)
+		cacheFile.Close()
+		if (err == nil && parser.SourceHash() == sourceHash) {
+			return parser, nil
+		}
+	}
+
+	parser, err := NewParser(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := parser.Save(&buffer); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cachePath, buffer.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf(`Error writing parser cache: %w`, err)
+	}
+	return parser, nil
+}
@@ -0,0 +1,87 @@
+package lr1parser
+
+import (
+	"errors"
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/symbols"
+	"strings"
+)
+
+var ErrMissingEOF = errors.New(`Token stream is not terminated by an EOF token`)
+
+// Returns `ErrMissingEOF` unless `tokens` ends with an EOF token, as every driver
+// expects.
+func CheckTokenStream(tokens []*lexer.Token) error {
+	if (len(tokens) == 0 || tokens[len(tokens) - 1].Type != symbols.EOF) {
+		return ErrMissingEOF
+	}
+	return nil
+}
+
+/*
+Error returned when the driver reaches an empty ACTION cell: `Token` cannot follow
+the input parsed so far. `Expected` lists the terminals that would have been valid
+instead, in column order.
+*/
+type SyntaxError struct {
+	Token *lexer.Token
+	Line uint
+	Col uint
+	Expected []string
+
+	displayName func (terminal string) string
+}
+
+// `displayName` renders the expected terminals, e.g. `lr1grammar.Grammar.DisplayName`.
+func NewSyntaxError(displayName func (terminal string) string, token *lexer.Token, expected []string) *SyntaxError {
+	return &SyntaxError{token, token.Line, token.Col, expected, displayName}
+}
+
+// Renders the error, e.g. `unexpected '}' at 3:5, expected str_lit or num_lit`.
+// Terminals are shown by their display name.
+func (e *SyntaxError) Error() string {
+	var message string
+	if (e.Token.Type == symbols.EOF) {
+		message = fmt.Sprintf(`unexpected end of input at %d:%d`, e.Line, e.Col)
+	} else {
+		message = fmt.Sprintf(`unexpected '%s' at %d:%d`, e.Token.Value, e.Line, e.Col)
+	}
+	if (len(e.Expected) == 0) {
+		return message
+	}
+
+	names := make([]string, len(e.Expected))
+	for idx, terminal := range e.Expected {
+		names[idx] = e.displayName(terminal)
+	}
+	return message + ", expected " + joinAlternatives(names)
+}
+
+// Joins names as `a`, `a or b`, `a, b or c`.
+func joinAlternatives(names []string) string {
+	if (len(names) == 1) {
+		return names[0]
+	}
+	return strings.Join(names[:len(names) - 1], ", ") + " or " + names[len(names) - 1]
+}
+
+// Syntax errors reported while recovering from errors, in input order.
+type SyntaxErrors []*SyntaxError
+
+// Renders one error per line.
+func (errs SyntaxErrors) Error() string {
+	messages := make([]string, len(errs))
+	for idx, err := range errs {
+		messages[idx] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (errs SyntaxErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for idx, err := range errs {
+		unwrapped[idx] = err
+	}
+	return unwrapped
+}
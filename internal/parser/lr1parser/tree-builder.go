@@ -0,0 +1,105 @@
+package lr1parser
+
+import (
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"slices"
+)
+
+/*
+Builds a concrete syntax tree: a `cst.Leaf` per token and a `cst.Node` per reduction,
+shaped by the `lr1grammar.ProductionAnnotations` of each production. Values are
+opaque: pass the values returned by `Shift` and `Reduce` back as children and
+resolve the value of the start symbol with `Root`. Other drivers use it to build the
+same trees as the LR(1) driver.
+*/
+type TreeBuilder struct {}
+
+// A node whose children are spliced into its parent.
+type inlinedNode struct {
+	node *cst.Node
+}
+
+func (TreeBuilder) Shift(token *lexer.Token) any {
+	return cst.NewLeaf(token)
+}
+
+// Collects the skipped elements into an error node.
+func (TreeBuilder) Error(syntaxError *SyntaxError, skipped []any) any {
+	elements := []cst.Element{}
+	for _, value := range skipped {
+		switch value := value.(type) {
+		case inlinedNode:
+			elements = append(elements, value.node.Children...)
+		case cst.Element:
+			elements = append(elements, value)
+		}
+	}
+	return cst.NewErrorNode(elements)
+}
+
+func (TreeBuilder) Reduce(ruleId int, productionRule lr1grammar.ProductionRule, children []any) (any, error) {
+	annotations := productionRule.Annotations
+	elements := make([]cst.Element, 0, len(children))
+	var fields map[string]cst.Element
+	// covers the dropped children too
+	span := cst.Span{}
+
+	for idx, child := range children {
+		switch child := child.(type) {
+		case inlinedNode:
+			span = span.Merge(child.node.Span())
+		case cst.Element:
+			span = span.Merge(child.Span())
+		}
+		if (slices.Contains(annotations.Drop, productionRule.Production[idx])) {
+			continue
+		}
+
+		var contributed []cst.Element
+		switch child := child.(type) {
+		case inlinedNode:
+			contributed = child.node.Children
+		case cst.Element:
+			contributed = []cst.Element{child}
+		}
+
+		// fields refer to the first element contributed by their RHS symbol
+		for field, fieldIdx := range annotations.Fields {
+			if (fieldIdx == idx && len(contributed) > 0) {
+				if (fields == nil) {
+					fields = make(map[string]cst.Element)
+				}
+				fields[field] = contributed[0]
+			}
+		}
+		elements = append(elements, contributed...)
+	}
+
+	node := cst.NewNode(productionRule.NonTerminal, ruleId, elements)
+	node.Fields = fields
+	node.SourceSpan = span
+	if (annotations.Node != "") {
+		node.Kind = annotations.Node
+	}
+	if (annotations.Inline) {
+		return inlinedNode{node}, nil
+	}
+	return node, nil
+}
+
+// Resolves the value of the start symbol to the root of the tree. An inlined root is
+// replaced by its only child if that child is a node, and kept otherwise.
+func (TreeBuilder) Root(value any) *cst.Node {
+	inlined, isInlined := value.(inlinedNode)
+	if (!isInlined) {
+		return value.(*cst.Node)
+	}
+	if (len(inlined.node.Children) == 1) {
+		if child, isNode := inlined.node.Children[0].(*cst.Node); isNode {
+			return child
+		}
+	}
+	return inlined.node
+}
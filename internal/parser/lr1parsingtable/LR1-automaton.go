@@ -2,45 +2,38 @@ package lr1parsingtable
 
 import (
 	"errors"
-	"interpreters/internal/parser/firstfollow"
 	"interpreters/internal/parser/lr1closureset"
 	"interpreters/internal/parser/lr1grammar"
 	"interpreters/internal/parser/lr1item"
 	"interpreters/internal/symbols"
-	"interpreters/utilities/arrays"
-	"interpreters/utilities/sets"
+	"interpreters/utilities/bitsets"
+	"sort"
 )
 
 type LR1Automaton struct {
 	grammar *lr1grammar.Grammar
-	FIRSTSets map[string]sets.Set[string]
 	States map[int]ParserState
 }
 
 func NewLR1Automaton(grammar *lr1grammar.Grammar) (*LR1Automaton, error) {
-	FIRSTSets := firstfollow.ComputeFIRSTSets(grammar)
 	states := make(map[int]ParserState)
-	automaton := LR1Automaton{grammar, FIRSTSets, states}
+	automaton := LR1Automaton{grammar, states}
 
 	// verify that grammar is properly augmented
-	augmentedProduction := grammar.GetProductionsOfNonTerminal(symbols.AugmentedStart)
-	if (len(augmentedProduction) != 1) {
+	augmentedStart, exists := grammar.Symbols.Id(symbols.AugmentedStart)
+	if (!exists || len(grammar.RulesOf(augmentedStart)) != 1) {
 		return nil, errors.New("LR1Automaton requires an augmented grammar")
 	}
 
 	// initialize I_0 with the augmented start production rule
-	originalStartSymbol := augmentedProduction[0].Production[0]
-	firstItem, err := lr1item.NewLR1Item(
-		symbols.AugmentedStart, 
-		[]string{originalStartSymbol},
+	eof, _ := grammar.Symbols.Id(symbols.EOF)
+	firstItem := lr1item.NewLR1Item(
+		grammar.RulesOf(augmentedStart)[0],
 		0,
-		sets.NewSet(symbols.EOF),
+		bitsets.Of(grammar.Symbols.NumTerminals(), eof),
 	)
-	if err != nil {
-		return nil, err
-	}
 
-	I0ClosureSet := lr1closureset.NewLR1ClosureSet(firstItem)
+	I0ClosureSet := automaton.CLOSURE(lr1closureset.NewLR1ClosureSet(firstItem))
 	I0NextStates := make(map[string]int)
 	I0 := ParserState{
 		I0ClosureSet,
@@ -48,47 +41,133 @@ func NewLR1Automaton(grammar *lr1grammar.Grammar) (*LR1Automaton, error) {
 	}
 	automaton.States[0] = I0
 
-	return &automaton, nil
-}
+	// explore states breadth first: state IDs are assigned in discovery order and
+	// transition symbols are visited in name order so that numbering is stable
+	stateIds := map[string]int{I0ClosureSet.GetKernelKey(): 0}
+	for stateId := 0; stateId < len(automaton.States); stateId++ {
+		state := automaton.States[stateId]
 
-func (automaton *LR1Automaton) Lookahead(context []string, currLookahead sets.Set[string]) sets.Set[string] {
-	if len(context) == 0 {
-		return currLookahead.Clone()
-	} else if context[0] == symbols.Epsilon {
-		return currLookahead.Clone()
-	} else {
-		lookaheadSet := sets.NewEmptySet[string]()
-		for _, symbol := range context {
-			if automaton.grammar.Terminals.Has(symbol) {
-				// symbol is a terminal: this is the only possible lookahead
-				lookaheadSet.Add(symbol)
-				break
-			} else {
-				// symbol is a non-terminal: FIRST(symbol) is in lookaheadSet
-				symbolFIRSTSet := automaton.FIRSTSets[symbol]
-				lookaheadSet = lookaheadSet.Union(symbolFIRSTSet)
-				if !automaton.grammar.DerivesEpsilon(symbol) {
-					// non-terminal cannot derive Epsilon: no other possible lookaheads
-					return lookaheadSet
+		for _, symbol := range automaton.transitionSymbols(state.CLOSURESet) {
+			nextClosureSet := automaton.GOTO(state.CLOSURESet, symbol)
+
+			kernelKey := nextClosureSet.GetKernelKey()
+			nextStateId, exists := stateIds[kernelKey]
+			if (!exists) {
+				nextStateId = len(automaton.States)
+				stateIds[kernelKey] = nextStateId
+				automaton.States[nextStateId] = ParserState{
+					nextClosureSet,
+					make(map[string]int),
 				}
 			}
+			state.NextStates[grammar.Symbols.Name(symbol)] = nextStateId
+		}
+	}
+
+	return &automaton, nil
+}
+
+// Get the IDs of all symbols that can follow the `dot` of the items of a
+// `LR1ClosureSet`, ordered by name.
+func (automaton *LR1Automaton) transitionSymbols(closureSet *lr1closureset.LR1ClosureSet) []int {
+	seen := bitsets.New(automaton.grammar.Symbols.Len())
+	transitionSymbols := []int{}
+	for _, item := range closureSet.GetItems() {
+		if symbol := item.NextSymbol(automaton.grammar); symbol >= 0 && !seen.Has(symbol) {
+			seen.Add(symbol)
+			transitionSymbols = append(transitionSymbols, symbol)
 		}
+	}
+	sort.Slice(transitionSymbols, func (i int, j int) bool {
+		return automaton.grammar.Symbols.Name(transitionSymbols[i]) < automaton.grammar.Symbols.Name(transitionSymbols[j])
+	})
+	return transitionSymbols
+}
 
-		// reached the end of `context` and all previous symbols could derive Epsilon
-		// so we also include the parent item's lookahead set
-		return lookaheadSet.Union(currLookahead)
+// Computes the lookahead set for a given context: sequence of symbols following a
+// non-terminal to the RHS of the parsing progress (the dot).
+func (automaton *LR1Automaton) lookahead(context []int, currLookahead bitsets.Bitset) bitsets.Bitset {
+	lookaheadSet := bitsets.New(automaton.grammar.Symbols.NumTerminals())
+	for _, symbol := range context {
+		// FIRST of a terminal is the terminal itself
+		lookaheadSet.UnionWith(automaton.grammar.FIRST(symbol))
+		if (!automaton.grammar.IsNullable(symbol)) {
+			// symbol cannot derive Epsilon: no other possible lookaheads
+			return lookaheadSet
+		}
 	}
+
+	// reached the end of `context` and all previous symbols could derive Epsilon
+	// so we also include the parent item's lookahead set
+	lookaheadSet.UnionWith(currLookahead)
+	return lookaheadSet
 }
 
-func (automaton *LR1Automaton) CLOSURE(item *lr1item.LR1Item) *lr1closureset.LR1ClosureSet {
-	result := lr1closureset.NewEmptyLR1ClosureSet()
-	result.Add(item)
+/*
+Expands a `LR1ClosureSet` in place with the items of every non-terminal that can
+follow the `dot` of its items.
+
+Items added by the closure all have the dot at the start of the RHS, so all the
+items of a non-terminal share one lookahead set. Those sets are propagated to a
+fixpoint first and the items are added once at the end.
+*/
+func (automaton *LR1Automaton) CLOSURE(closureSet *lr1closureset.LR1ClosureSet) *lr1closureset.LR1ClosureSet {
+	grammar := automaton.grammar
+	lookaheads := make([]bitsets.Bitset, grammar.Symbols.Len())
+	pending := []int{}
+
+	propagate := func (nonTerminal int, lookaheadSet bitsets.Bitset) {
+		if (lookaheads[nonTerminal] == nil) {
+			lookaheads[nonTerminal] = lookaheadSet
+			pending = append(pending, nonTerminal)
+		} else if (lookaheads[nonTerminal].UnionWith(lookaheadSet)) {
+			pending = append(pending, nonTerminal)
+		}
+	}
+
+	for _, item := range closureSet.GetItems() {
+		nextSymbol := item.NextSymbol(grammar)
+		if (nextSymbol >= 0 && !grammar.Symbols.IsTerminal(nextSymbol)) {
+			propagate(nextSymbol, automaton.lookahead(item.ContextForNextSymbol(grammar), item.Lookaheads))
+		}
+	}
+	for len(pending) > 0 {
+		nonTerminal := pending[len(pending) - 1]
+		pending = pending[:len(pending) - 1]
+
+		for _, ruleId := range grammar.RulesOf(nonTerminal) {
+			rhs := grammar.RuleRHS(ruleId)
+			if (len(rhs) > 0 && !grammar.Symbols.IsTerminal(rhs[0])) {
+				propagate(rhs[0], automaton.lookahead(rhs[1:], lookaheads[nonTerminal]))
+			}
+		}
+	}
+
+	for nonTerminal, lookaheadSet := range lookaheads {
+		for _, ruleId := range grammar.RulesOf(nonTerminal) {
+			if (lookaheadSet != nil) {
+				closureSet.Add(lr1item.NewLR1Item(ruleId, 0, lookaheadSet))
+			}
+		}
+	}
+
+	return closureSet
+}
 
-	changed := true
-	for changed {
-		nextSymbols := result.GetTransitionSymbols()
-		nextNonTerminalSymbols := arrays.Filter(nextSymbols.GetItems(), func (symbol string) bool {
-			return automaton.grammar.NonTerminals.Has(symbol)
-		})
+// Computes the closure of all items in `closureSet` whose `dot` can advance over
+// the symbol with ID `symbol`.
+func (automaton *LR1Automaton) GOTO(closureSet *lr1closureset.LR1ClosureSet, symbol int) *lr1closureset.LR1ClosureSet {
+	kernelItems := []*lr1item.LR1Item{}
+	for _, item := range closureSet.GetItems() {
+		if (item.NextSymbol(automaton.grammar) == symbol) {
+			kernelItems = append(kernelItems, item.AdvanceDot())
+		}
 	}
-}
\ No newline at end of file
+
+	return automaton.CLOSURE(lr1closureset.NewLR1ClosureSet(kernelItems...))
+}
+
+// Get the `Grammar` the automaton was built from.
+func (automaton *LR1Automaton) GetGrammar() *lr1grammar.Grammar {
+	return automaton.grammar
+}
@@ -1,64 +1,211 @@
 package lr1parsingtable
 
 import (
-	"interpreters/internal/parser/firstfollow"
+	"errors"
+	"fmt"
 	"interpreters/internal/parser/lr1grammar"
-	"interpreters/internal/parser/lr1item"
 	"interpreters/internal/symbols"
-	"interpreters/utilities/sets"
+	"sort"
+	"strings"
 )
 
 type ParsingTable struct {
 	Grammar *lr1grammar.Grammar
-	FIRSTSets map[string]sets.Set[string]
-	table map[string]map[string]ParserAction
+	Automaton *LR1Automaton
+	Conflicts []Conflict
+	table *compressedTable
+	// every action of the conflicting cells
+	conflicting map[cell][]ParserAction
 }
 
-func NewLR1ParsingTable(grammar *lr1grammar.Grammar) *ParsingTable {
-	FIRSTSets := firstfollow.ComputeFIRSTSets(grammar)
-	table := make(map[string]map[string]ParserAction)
+type cell struct {
+	state int
+	symbol string
+}
+
+// Two or more actions competing for the same ACTION table cell.
+type Conflict struct {
+	State int
+	Symbol string
+	Actions []ParserAction
+}
+
+// Either "shift/reduce" or "reduce/reduce".
+func (c Conflict) Kind() string {
+	for _, action := range c.Actions {
+		if action.ActionVerb() == SHIFT {
+			return "shift/reduce"
+		}
+	}
+	return "reduce/reduce"
+}
+
+func (c Conflict) String() string {
+	messages := make([]string, len(c.Actions))
+	for idx, action := range c.Actions {
+		messages[idx] = action.Message()
+	}
+	return fmt.Sprintf(
+		"%s conflict in state %d on symbol %s: %s",
+		c.Kind(),
+		c.State,
+		c.Symbol,
+		strings.Join(messages, ", "),
+	)
+}
+
+// Builds the canonical LR(1) ACTION and GOTO tables of an augmented `Grammar`. An
+// error is returned if the grammar is not LR(1); the conflicting cells are listed in
+// the error message.
+func NewLR1ParsingTable(grammar *lr1grammar.Grammar) (*ParsingTable, error) {
+	parsingTable, err := NewGLRParsingTable(grammar)
+	if err != nil {
+		return nil, err
+	}
+
+	if (len(parsingTable.Conflicts) > 0) {
+		messages := make([]string, len(parsingTable.Conflicts))
+		for idx, conflict := range parsingTable.Conflicts {
+			messages[idx] = conflict.String()
+		}
+		return nil, errors.New("Grammar is not LR(1): " + strings.Join(messages, "; "))
+	}
+
+	return parsingTable, nil
+}
 
+/*
+Builds the canonical LR(1) tables of an augmented `Grammar` without rejecting
+conflicts, for generalized (GLR) parsing. `Action` yields the first action of a
+conflicting cell and `Actions` all of them; the cells are listed in `Conflicts`.
+*/
+func NewGLRParsingTable(grammar *lr1grammar.Grammar) (*ParsingTable, error) {
+	automaton, err := NewLR1Automaton(grammar)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]ParserAction, len(automaton.States))
 	parsingTable := ParsingTable{
 		grammar,
-		FIRSTSets,
-		table,
+		automaton,
+		[]Conflict{},
+		nil,
+		map[cell][]ParserAction{},
+	}
+
+	for stateId := 0; stateId < len(automaton.States); stateId++ {
+		row := make(map[string]ParserAction)
+		rows[stateId] = row
+
+		candidateActions := automaton.candidateActions(stateId)
+		for symbol, actions := range candidateActions {
+			row[symbol] = actions[0]
+		}
+		for _, conflict := range stateConflicts(stateId, candidateActions) {
+			parsingTable.Conflicts = append(parsingTable.Conflicts, conflict)
+			parsingTable.conflicting[cell{stateId, conflict.Symbol}] = conflict.Actions
+		}
 	}
 
-	return &parsingTable
+	parsingTable.table = newCompressedTable(grammar, rows)
+	return &parsingTable, nil
 }
 
-func (pt *ParsingTable) CLOSURE(item lr1item.LR1Item) {}
-
-func (pt *ParsingTable) GOTO(item lr1item.LR1Item) {}
-
-
-// Computes the lookahead set for a given context: sequence of symbols following a
-// non-terminal to the RHS of the parsing progress (the dot).
-func (pt *ParsingTable) Lookahead(context []string, currLookahead sets.Set[string]) sets.Set[string] {
-	if len(context) == 0 {
-		return currLookahead.Clone()
-	} else if context[0] == symbols.Epsilon {
-		return currLookahead.Clone()
-	} else {
-		lookaheadSet := sets.NewEmptySet[string]()
-		for _, symbol := range context {
-			if pt.Grammar.Terminals.Has(symbol) {
-				// symbol is a terminal: this is the only possible lookahead
-				lookaheadSet.Add(symbol)
-				break
-			} else {
-				// symbol is a non-terminal: FIRST(symbol) is in lookaheadSet
-				symbolFIRSTSet := pt.FIRSTSets[symbol]
-				lookaheadSet = lookaheadSet.Union(symbolFIRSTSet)
-				if !pt.Grammar.DerivesEpsilon(symbol) {
-					// non-terminal cannot derive Epsilon: no other possible lookaheads
-					return lookaheadSet
-				}
-			}
+// Collects every action a state could take for each symbol. More than one action
+// for a symbol is a conflict.
+func (automaton *LR1Automaton) candidateActions(stateId int) map[string][]ParserAction {
+	state := automaton.States[stateId]
+	actions := make(map[string][]ParserAction)
+
+	// transitions: SHIFT over terminals, GOTO over non-terminals
+	for symbol, nextState := range state.NextStates {
+		if (automaton.grammar.NonTerminals.Has(symbol)) {
+			actions[symbol] = []ParserAction{NewGotoAction(nextState)}
+		} else {
+			actions[symbol] = []ParserAction{NewShiftAction(nextState, symbol)}
+		}
+	}
+
+	// completed items: REDUCE (or ACCEPT for the augmented start) on their lookaheads
+	grammar := automaton.grammar
+	for _, item := range sortedItems(grammar, state.CLOSURESet) {
+		if (!item.IsComplete(grammar)) {
+			continue
 		}
 
-		// reached the end of `context` and all previous symbols could derive Epsilon
-		// so we also include the parent item's lookahead set
-		return lookaheadSet.Union(currLookahead)
+		if (grammar.Symbols.Name(grammar.RuleLHS(item.Rule)) == symbols.AugmentedStart) {
+			actions[symbols.EOF] = append(actions[symbols.EOF], &AcceptAction{})
+			continue
+		}
+
+		for _, lookahead := range item.GetLookaheads(grammar) {
+			actions[lookahead] = append(actions[lookahead], NewReduceAction(item.Rule))
+		}
+	}
+
+	return actions
+}
+
+// The conflicts among the candidate actions of a state, ordered by symbol.
+func stateConflicts(stateId int, candidateActions map[string][]ParserAction) []Conflict {
+	conflicts := []Conflict{}
+	for _, symbol := range sortedKeys(candidateActions) {
+		if actions := candidateActions[symbol]; len(actions) > 1 {
+			conflicts = append(conflicts, Conflict{stateId, symbol, actions})
+		}
+	}
+	return conflicts
+}
+
+// Get the ACTION for a state on a terminal. Empty cells yield an `ErrorAction`.
+func (pt *ParsingTable) Action(state int, terminal string) ParserAction {
+	action := pt.table.lookup(state, terminal)
+	if (action == nil) {
+		return NewErrorAction(fmt.Sprintf("no action for symbol: %s in state: %d", terminal, state))
+	}
+	return action
+}
+
+// Get every action for a state on a terminal: all the actions of a conflicting cell,
+// none for an empty cell.
+func (pt *ParsingTable) Actions(state int, terminal string) []ParserAction {
+	if actions, exists := pt.conflicting[cell{state, terminal}]; exists {
+		return actions
 	}
-}
\ No newline at end of file
+	if action := pt.table.lookup(state, terminal); action != nil {
+		return []ParserAction{action}
+	}
+	return nil
+}
+
+// Get the GOTO for a state on a non-terminal. Empty cells yield an `ErrorAction`.
+func (pt *ParsingTable) Goto(state int, nonTerminal string) ParserAction {
+	return pt.Action(state, nonTerminal)
+}
+
+// Get the terminals with a non-error ACTION in a state, in column order. The error
+// terminal is never expected.
+func (pt *ParsingTable) ExpectedTerminals(state int) []string {
+	expected := []string{}
+	for _, terminal := range pt.ActionSymbols() {
+		if (terminal != symbols.Error && pt.table.lookup(state, terminal) != nil) {
+			expected = append(expected, terminal)
+		}
+	}
+	return expected
+}
+
+// Number of states (rows) in the table.
+func (pt *ParsingTable) NumStates() int {
+	return pt.table.numStates
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,174 @@
+package lr1parsingtable
+
+import (
+	"fmt"
+	"interpreters/internal/parser/lr1closureset"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1item"
+	"sort"
+	"strings"
+)
+
+type AutomatonExportOptions struct {
+	// mark states with ACTION conflicts and list the conflicts in their label
+	HighlightConflicts bool
+	// only list kernel items, omitting the items added by CLOSURE
+	KernelOnly bool
+}
+
+// Collects the ACTION conflicts of every state, ordered by state and symbol.
+func (automaton *LR1Automaton) FindConflicts() []Conflict {
+	conflicts := []Conflict{}
+	for stateId := 0; stateId < len(automaton.States); stateId++ {
+		conflicts = append(conflicts, stateConflicts(stateId, automaton.candidateActions(stateId))...)
+	}
+	return conflicts
+}
+
+// Renders an item in dot notation followed by its lookaheads, e.g.
+// `ENTRY -> KEY • : VALUE, }/,`.
+func (automaton *LR1Automaton) FormatItem(item *lr1item.LR1Item) string {
+	return item.GetName(automaton.grammar) + ", " + strings.Join(item.GetLookaheads(automaton.grammar), "/")
+}
+
+// Get the items of a state ordered by name, kernel items first.
+func sortedItems(grammar *lr1grammar.Grammar, closureSet *lr1closureset.LR1ClosureSet) []*lr1item.LR1Item {
+	items := closureSet.GetSortedItems()
+	sort.SliceStable(items, func (i int, j int) bool {
+		iIsKernel, jIsKernel := closureSet.IsKernelItem(items[i]), closureSet.IsKernelItem(items[j])
+		if (iIsKernel != jIsKernel) {
+			return iIsKernel
+		}
+		return items[i].GetName(grammar) < items[j].GetName(grammar)
+	})
+	return items
+}
+
+// Lines describing a state: its items, kernel items first, and its conflicts.
+func (automaton *LR1Automaton) stateLines(stateId int, conflicts []Conflict, options AutomatonExportOptions) []string {
+	closureSet := automaton.States[stateId].CLOSURESet
+	lines := []string{fmt.Sprintf("I%d", stateId)}
+
+	closureItems := []string{}
+	for _, item := range sortedItems(automaton.grammar, closureSet) {
+		if (closureSet.IsKernelItem(item)) {
+			lines = append(lines, automaton.FormatItem(item))
+		} else {
+			closureItems = append(closureItems, automaton.FormatItem(item))
+		}
+	}
+	if (!options.KernelOnly) {
+		if (len(closureItems) > 0) {
+			lines = append(lines, "--")
+			lines = append(lines, closureItems...)
+		}
+	}
+
+	for _, conflict := range conflicts {
+		lines = append(lines, "!! " + conflict.String())
+	}
+	return lines
+}
+
+// Groups the conflicts to highlight by state. Empty unless requested.
+func (automaton *LR1Automaton) conflictsByState(options AutomatonExportOptions) map[int][]Conflict {
+	byState := make(map[int][]Conflict)
+	if (!options.HighlightConflicts) {
+		return byState
+	}
+
+	for _, conflict := range automaton.FindConflicts() {
+		byState[conflict.State] = append(byState[conflict.State], conflict)
+	}
+	return byState
+}
+
+// Renders the automaton as a Graphviz DOT digraph with one node per `ParserState`
+// and one edge per transition in `NextStates`.
+func (automaton *LR1Automaton) ToDOT(options AutomatonExportOptions) string {
+	conflicts := automaton.conflictsByState(options)
+
+	var builder strings.Builder
+	builder.WriteString("digraph LR1Automaton {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	builder.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	for stateId := 0; stateId < len(automaton.States); stateId++ {
+		lines := automaton.stateLines(stateId, conflicts[stateId], options)
+		escaped := make([]string, len(lines))
+		for idx, line := range lines {
+			escaped[idx] = escapeDOT(line)
+		}
+		// `\l` left-justifies every line of the label
+		label := `"` + strings.Join(escaped, `\l`) + `\l"`
+
+		style := ""
+		if (len(conflicts[stateId]) > 0) {
+			style = ", style=filled, fillcolor=\"#ffb3b3\", color=\"#cc0000\""
+		}
+		builder.WriteString(fmt.Sprintf("  I%d [label=%s%s];\n", stateId, label, style))
+	}
+
+	automaton.writeTransitions(func (from int, symbol string, to int) {
+		builder.WriteString(fmt.Sprintf("  I%d -> I%d [label=\"%s\"];\n", from, to, escapeDOT(symbol)))
+	})
+
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// Renders the automaton as a Mermaid flowchart with one node per `ParserState` and
+// one edge per transition in `NextStates`.
+func (automaton *LR1Automaton) ToMermaid(options AutomatonExportOptions) string {
+	conflicts := automaton.conflictsByState(options)
+
+	var builder strings.Builder
+	builder.WriteString("flowchart LR\n")
+
+	conflictingStates := []string{}
+	for stateId := 0; stateId < len(automaton.States); stateId++ {
+		lines := automaton.stateLines(stateId, conflicts[stateId], options)
+		escaped := make([]string, len(lines))
+		for idx, line := range lines {
+			escaped[idx] = escapeMermaid(line)
+		}
+		builder.WriteString(fmt.Sprintf("  I%d[\"%s\"]\n", stateId, strings.Join(escaped, "<br/>")))
+
+		if (len(conflicts[stateId]) > 0) {
+			conflictingStates = append(conflictingStates, fmt.Sprintf("I%d", stateId))
+		}
+	}
+
+	automaton.writeTransitions(func (from int, symbol string, to int) {
+		builder.WriteString(fmt.Sprintf("  I%d -->|\"%s\"| I%d\n", from, escapeMermaid(symbol), to))
+	})
+
+	if (len(conflictingStates) > 0) {
+		builder.WriteString("  classDef conflict fill:#ffb3b3,stroke:#cc0000\n")
+		builder.WriteString(fmt.Sprintf("  class %s conflict\n", strings.Join(conflictingStates, ",")))
+	}
+	return builder.String()
+}
+
+// Visits every transition ordered by source state and symbol.
+func (automaton *LR1Automaton) writeTransitions(write func (from int, symbol string, to int)) {
+	for stateId := 0; stateId < len(automaton.States); stateId++ {
+		nextStates := automaton.States[stateId].NextStates
+		for _, symbol := range sortedKeys(nextStates) {
+			write(stateId, symbol, nextStates[symbol])
+		}
+	}
+}
+
+func escapeDOT(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+}
+
+// Mermaid labels use HTML entity codes for characters that break its syntax.
+func escapeMermaid(text string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+	).Replace(text)
+}
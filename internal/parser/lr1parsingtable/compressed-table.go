@@ -0,0 +1,172 @@
+package lr1parsingtable
+
+import (
+	"interpreters/internal/parser/lr1grammar"
+	"sort"
+)
+
+/*
+Read-only ACTION/GOTO table packed into flat integer arrays.
+
+Symbols are numbered by column (ACTION columns first, then GOTO columns) and every
+distinct action is stored once in `actions`; cells hold an index into it plus one,
+0 being an empty cell.
+
+The most frequent reduction of each state becomes its default reduction and is
+dropped from the row. A bitset remembers which terminals the default applies to,
+so empty cells still yield an error.
+
+The remaining cells of all rows are overlaid in a single array (row displacement,
+or "comb" compression): the cell of state `s` and column `c` is at `base[s] + c`
+and belongs to `s` only if `check[base[s] + c] == s`.
+*/
+type compressedTable struct {
+	symbolIds map[string]int
+	numTerminals int
+	numStates int
+
+	actions []ParserAction
+
+	defaults []int32
+	// per state bitset over terminal columns reduced by the default action
+	defaultColumns []uint64
+	bitsetStride int
+
+	base []int32
+	check []int32
+	cells []int32
+}
+
+// Non-empty cell of a row before placement: column and interned action id.
+type compressedCell struct {
+	column int
+	action int32
+}
+
+// Packs the rows of a table, one map of symbol -> action per state.
+func newCompressedTable(grammar *lr1grammar.Grammar, rows []map[string]ParserAction) *compressedTable {
+	terminals := actionSymbols(grammar)
+	nonTerminals := gotoSymbols(grammar)
+
+	ct := compressedTable{
+		symbolIds: make(map[string]int, len(terminals) + len(nonTerminals)),
+		numTerminals: len(terminals),
+		numStates: len(rows),
+		defaults: make([]int32, len(rows)),
+		bitsetStride: (len(terminals) + 63) / 64,
+		base: make([]int32, len(rows)),
+	}
+	ct.defaultColumns = make([]uint64, len(rows) * ct.bitsetStride)
+	columns := append(terminals, nonTerminals...)
+	for idx, symbol := range columns {
+		ct.symbolIds[symbol] = idx
+	}
+
+	// intern actions: a shift always goes to a state entered by the same symbol,
+	// so the encoded form identifies an action
+	actionIds := make(map[string]int32)
+	intern := func (action ParserAction) int32 {
+		encoded := EncodeAction(action)
+		id, exists := actionIds[encoded]
+		if (!exists) {
+			ct.actions = append(ct.actions, action)
+			id = int32(len(ct.actions))
+			actionIds[encoded] = id
+		}
+		return id
+	}
+
+	explicit := make([][]compressedCell, len(rows))
+
+	for state, row := range rows {
+		// pick the most frequent reduction, the lowest rule on ties
+		reductions := make(map[int]int)
+		defaultRule := -1
+		for symbol, action := range row {
+			if (action.ActionVerb() != REDUCE || ct.symbolIds[symbol] >= ct.numTerminals) {
+				continue
+			}
+			ruleId := action.ReduceByRule()
+			reductions[ruleId]++
+			if (defaultRule < 0 || reductions[ruleId] > reductions[defaultRule] ||
+				(reductions[ruleId] == reductions[defaultRule] && ruleId < defaultRule)) {
+				defaultRule = ruleId
+			}
+		}
+
+		for column, symbol := range columns {
+			action, exists := row[symbol]
+			if (!exists) {
+				continue
+			}
+			if (defaultRule >= 0 && action.ActionVerb() == REDUCE && action.ReduceByRule() == defaultRule && column < ct.numTerminals) {
+				ct.defaults[state] = intern(action)
+				ct.defaultColumns[state * ct.bitsetStride + column / 64] |= 1 << (column % 64)
+				continue
+			}
+			explicit[state] = append(explicit[state], compressedCell{column, intern(action)})
+		}
+	}
+
+	// place the densest rows first, each at the lowest displacement that fits
+	order := make([]int, len(rows))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func (i int, j int) bool {
+		return len(explicit[order[i]]) > len(explicit[order[j]])
+	})
+
+	for _, state := range order {
+		row := explicit[state]
+		if (len(row) == 0) {
+			continue
+		}
+		base := -row[0].column
+		for !ct.fits(base, row) {
+			base++
+		}
+
+		ct.base[state] = int32(base)
+		for _, c := range row {
+			idx := base + c.column
+			for len(ct.cells) <= idx {
+				ct.cells = append(ct.cells, 0)
+				ct.check = append(ct.check, -1)
+			}
+			ct.cells[idx] = c.action
+			ct.check[idx] = int32(state)
+		}
+	}
+
+	return &ct
+}
+
+// Checks whether the cells of a row can be placed at `base` without overlapping
+// the cells of rows placed before.
+func (ct *compressedTable) fits(base int, row []compressedCell) bool {
+	for _, c := range row {
+		idx := base + c.column
+		if (idx < len(ct.check) && ct.check[idx] >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the action of a cell, `nil` for empty cells and unknown symbols.
+func (ct *compressedTable) lookup(state int, symbol string) ParserAction {
+	column, exists := ct.symbolIds[symbol]
+	if (!exists || state < 0 || state >= ct.numStates) {
+		return nil
+	}
+
+	idx := int(ct.base[state]) + column
+	if (idx >= 0 && idx < len(ct.check) && ct.check[idx] == int32(state)) {
+		return ct.actions[ct.cells[idx] - 1]
+	}
+	if (column < ct.numTerminals && ct.defaultColumns[state * ct.bitsetStride + column / 64] & (1 << (column % 64)) != 0) {
+		return ct.actions[ct.defaults[state] - 1]
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package lr1parsingtable
+
+import (
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The uncompressed representation: state -> symbol -> action.
+type mapTable map[string]map[string]ParserAction
+
+func (mt mapTable) lookup(state int, symbol string) ParserAction {
+	return mt[strconv.Itoa(state)][symbol]
+}
+
+func newJsonParsingTable(tb testing.TB) (*ParsingTable, mapTable) {
+	config, err := lr1grammar.ReadGrammarConfigJson("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		tb.Fatal(err)
+	}
+	grammar := lr1grammar.MustNewAugmentedGrammar(config)
+	table, err := NewLR1ParsingTable(grammar)
+	if (err != nil) {
+		tb.Fatal(err)
+	}
+	return table, newMapTable(tb, table.Automaton)
+}
+
+func newMapTable(tb testing.TB, automaton *LR1Automaton) mapTable {
+	table := make(mapTable)
+	for state := range automaton.States {
+		candidateActions := automaton.candidateActions(state)
+		row := make(map[string]ParserAction)
+		for symbol, actions := range candidateActions {
+			row[symbol] = actions[0]
+		}
+		table[strconv.Itoa(state)] = row
+	}
+	return table
+}
+
+func TestCompressedTableMatchesMapTable(t *testing.T) {
+	table, reference := newJsonParsingTable(t)
+	columns := append(table.ActionSymbols(), table.GotoSymbols()...)
+	columns = append(columns, "unknown")
+
+	for state := 0; state < table.NumStates(); state++ {
+		for _, symbol := range columns {
+			expected := reference.lookup(state, symbol)
+			actual := table.table.lookup(state, symbol)
+			if (expected == nil && actual != nil) || (expected != nil && (actual == nil || EncodeAction(expected) != EncodeAction(actual))) {
+				t.Errorf("state %d, symbol %s: expected %v, got %v", state, symbol, expected, actual)
+			}
+		}
+	}
+
+	if (len(table.table.cells) >= table.NumStates() * len(columns)) {
+		t.Errorf("table is not compressed: %d cells", len(table.table.cells))
+	}
+}
+
+// A JSON document with `n` nested entries.
+func benchmarkInput(n int) string {
+	var builder strings.Builder
+	builder.WriteString("[")
+	for idx := 0; idx < n; idx++ {
+		if (idx > 0) {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(`{ "a": [1, 2.5, true], "b": { "c": null, "d": "text" } }`)
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+// Runs the LR driver without building values and returns the number of reductions.
+func recognize(tb testing.TB, grammar *lr1grammar.Grammar, lookup func (state int, symbol string) ParserAction, tokens []*lexer.Token) int {
+	stack := []int{0}
+	reductions := 0
+	for tokenIdx := 0; ; {
+		action := lookup(stack[len(stack) - 1], tokens[tokenIdx].Type)
+		if (action == nil) {
+			tb.Fatalf("unexpected token %s", tokens[tokenIdx].Type)
+		}
+		switch action.ActionVerb() {
+		case SHIFT:
+			stack = append(stack, action.NextState())
+			tokenIdx++
+		case REDUCE:
+			rule := grammar.ProductionRules[uint(action.ReduceByRule())]
+			stack = stack[:len(stack) - rule.Length()]
+			stack = append(stack, lookup(stack[len(stack) - 1], rule.NonTerminal).NextState())
+			reductions++
+		case ACCEPT:
+			return reductions
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	table, reference := newJsonParsingTable(b)
+	config, err := lr1grammar.ReadGrammarConfigJson("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		b.Fatal(err)
+	}
+	lex, err := lexer.CreateLexer(config.Terminals)
+	if (err != nil) {
+		b.Fatal(err)
+	}
+	tokens := *lex.MustTokenize(benchmarkInput(1000))
+
+	b.Run("map", func (b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			recognize(b, table.Grammar, reference.lookup, tokens)
+		}
+	})
+	b.Run("compressed", func (b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			recognize(b, table.Grammar, table.table.lookup, tokens)
+		}
+	})
+}
+
+// Reports the heap retained by each representation of the same table.
+func BenchmarkTableMemory(b *testing.B) {
+	table, _ := newJsonParsingTable(b)
+	rows := make([]map[string]ParserAction, table.NumStates())
+	for state := range rows {
+		row := make(map[string]ParserAction)
+		for _, symbol := range append(table.ActionSymbols(), table.GotoSymbols()...) {
+			if action := table.table.lookup(state, symbol); action != nil {
+				row[symbol] = action
+			}
+		}
+		rows[state] = row
+	}
+
+	retained := func (build func () any) uint64 {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		value := build()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(value)
+		return after.HeapAlloc - before.HeapAlloc
+	}
+
+	b.Run("map", func (b *testing.B) {
+		var bytes uint64
+		for i := 0; i < b.N; i++ {
+			bytes = retained(func () any { return newMapTable(b, table.Automaton) })
+		}
+		b.ReportMetric(float64(bytes), "table-bytes")
+	})
+	b.Run("compressed", func (b *testing.B) {
+		var bytes uint64
+		for i := 0; i < b.N; i++ {
+			bytes = retained(func () any { return newCompressedTable(table.Grammar, rows) })
+		}
+		b.ReportMetric(float64(bytes), "table-bytes")
+	})
+}
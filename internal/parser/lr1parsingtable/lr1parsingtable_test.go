@@ -0,0 +1,179 @@
+package lr1parsingtable_test
+
+import (
+	"fmt"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parsingtable"
+	"strings"
+	"testing"
+)
+
+func newAutomaton(t *testing.T, nonTerminals map[string][]lr1grammar.ProductionJson, startSymbol string) *lr1parsingtable.LR1Automaton {
+	grammar := lr1grammar.MustNewAugmentedGrammar(lr1grammar.GrammarConfigJson{
+		NonTerminals: nonTerminals,
+		StartSymbol: startSymbol,
+	})
+	automaton, err := lr1parsingtable.NewLR1Automaton(grammar)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	return automaton
+}
+
+func TestAutomatonToDOT(t *testing.T) {
+	automaton := newAutomaton(t, map[string][]lr1grammar.ProductionJson{
+		"S": lr1grammar.NewProductionsJson([]string{"A", "'b'"}, []string{"'b'"}),
+		"A": lr1grammar.NewProductionsJson([]string{"'a'"}),
+	}, "S")
+
+	dot := automaton.ToDOT(lr1parsingtable.AutomatonExportOptions{})
+
+	expected := `digraph LR1Automaton {
+  rankdir=LR;
+  node [shape=box, fontname="monospace"];
+  I0 [label="I0\lG' -> • S, $\l--\lA -> • a, b\lS -> • A b, $\lS -> • b, $\l"];
+  I1 [label="I1\lS -> A • b, $\l"];
+  I2 [label="I2\lG' -> S •, $\l"];
+  I3 [label="I3\lA -> a •, b\l"];
+  I4 [label="I4\lS -> b •, $\l"];
+  I5 [label="I5\lS -> A b •, $\l"];
+  I0 -> I1 [label="A"];
+  I0 -> I2 [label="S"];
+  I0 -> I3 [label="a"];
+  I0 -> I4 [label="b"];
+  I1 -> I5 [label="b"];
+}
+`
+	if (dot != expected) {
+		t.Errorf("unexpected DOT output:\n%s", dot)
+	}
+}
+
+func TestAutomatonToMermaidHighlightsConflicts(t *testing.T) {
+	automaton := newAutomaton(t, map[string][]lr1grammar.ProductionJson{
+		"E": lr1grammar.NewProductionsJson([]string{"E", "'+'", "E"}, []string{"'n'"}),
+	}, "E")
+
+	mermaid := automaton.ToMermaid(lr1parsingtable.AutomatonExportOptions{
+		HighlightConflicts: true,
+		KernelOnly: true,
+	})
+
+	expected := `flowchart LR
+  I0["I0<br/>G' -#gt; • E, $"]
+  I1["I1<br/>E -#gt; E • + E, $/+<br/>G' -#gt; E •, $"]
+  I2["I2<br/>E -#gt; n •, $/+"]
+  I3["I3<br/>E -#gt; E + • E, $/+"]
+  I4["I4<br/>E -#gt; E + E •, $/+<br/>E -#gt; E • + E, $/+<br/>!! shift/reduce conflict in state 4 on symbol +: shift symbol: +, reduce by rule id: 1"]
+  I0 -->|"E"| I1
+  I0 -->|"n"| I2
+  I1 -->|"+"| I3
+  I3 -->|"E"| I4
+  I3 -->|"n"| I2
+  I4 -->|"+"| I3
+  classDef conflict fill:#ffb3b3,stroke:#cc0000
+  class I4 conflict
+`
+	if (mermaid != expected) {
+		t.Errorf("unexpected Mermaid output:\n%s", mermaid)
+	}
+}
+
+func TestTableReports(t *testing.T) {
+	grammar := lr1grammar.MustNewAugmentedGrammar(lr1grammar.GrammarConfigJson{
+		NonTerminals: map[string][]lr1grammar.ProductionJson{
+			"S": lr1grammar.NewProductionsJson([]string{"A", "'b'"}, []string{"'b'"}),
+			"A": lr1grammar.NewProductionsJson([]string{"'a'"}),
+		},
+		StartSymbol: "S",
+	})
+	table, err := lr1parsingtable.NewLR1ParsingTable(grammar)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	expectedText := ` State | a  | b  | $   || A | S
+ ----- | -- | -- | --- || - | -
+ 0     | s3 | s4 |     || 1 | 2
+ 1     |    | s5 |     ||   |
+ 2     |    |    | acc ||   |
+ 3     |    | r1 |     ||   |
+ 4     |    |    | r3  ||   |
+ 5     |    |    | r2  ||   |
+
+Rules:
+  r0: G' -> S
+  r1: A -> a
+  r2: S -> A b
+  r3: S -> b
+`
+	if (table.TextReport() != expectedText) {
+		t.Errorf("unexpected text report:\n%s", table.TextReport())
+	}
+
+	expectedMarkdown := "| `State` | `a` | `b` | `$` | `A` | `S` |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| 0 | s3 | s4 |  | 1 | 2 |\n" +
+		"| 1 |  | s5 |  |  |  |\n" +
+		"| 2 |  |  | acc |  |  |\n" +
+		"| 3 |  | r1 |  |  |  |\n" +
+		"| 4 |  |  | r3 |  |  |\n" +
+		"| 5 |  |  | r2 |  |  |\n" +
+		"\n**Rules**\n\n" +
+		"- r0: G' -> S\n" +
+		"- r1: A -> a\n" +
+		"- r2: S -> A b\n" +
+		"- r3: S -> b\n"
+	if (table.MarkdownReport() != expectedMarkdown) {
+		t.Errorf("unexpected Markdown report:\n%s", table.MarkdownReport())
+	}
+
+	html, err := table.HTMLReport()
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<td><a href="#state-3">s3</a></td>`,
+		`<td class="goto"><a href="#state-1">1</a></td>`,
+		`<td><a href="#rule-1">r1</a></td>`,
+		`<li id="rule-2"><code>S -&gt; A b</code></li>`,
+		"<section id=\"state-0\">\n<h3>State 0</h3>\n<pre>G&#39; -&gt; • S, $\n--\nA -&gt; • a, b\n",
+	} {
+		if (!strings.Contains(html, expected)) {
+			t.Errorf("expected HTML report to contain %s", expected)
+		}
+	}
+}
+
+// Expression grammar with `levels` binary operators of increasing precedence.
+func precedenceGrammar(levels int) map[string][]lr1grammar.ProductionJson {
+	nonTerminals := make(map[string][]lr1grammar.ProductionJson)
+	for level := 0; level < levels; level++ {
+		curr, next := fmt.Sprintf("E%d", level), fmt.Sprintf("E%d", level + 1)
+		nonTerminals[curr] = lr1grammar.NewProductionsJson(
+			[]string{curr, fmt.Sprintf("op%d", level), next},
+			[]string{next},
+		)
+	}
+	nonTerminals[fmt.Sprintf("E%d", levels)] = lr1grammar.NewProductionsJson(
+		[]string{"(", "E0", ")"},
+		[]string{"id"},
+	)
+	return nonTerminals
+}
+
+func BenchmarkNewLR1Automaton(b *testing.B) {
+	for _, levels := range []int{10, 50, 100} {
+		b.Run(fmt.Sprintf("%d productions", 2 * levels + 2), func (b *testing.B) {
+			grammar := lr1grammar.MustNewAugmentedGrammar(lr1grammar.GrammarConfigJson{
+				NonTerminals: precedenceGrammar(levels),
+				StartSymbol: "E0",
+			})
+			for i := 0; i < b.N; i++ {
+				if _, err := lr1parsingtable.NewLR1Automaton(grammar); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ func (a *ShiftAction) ActionVerb() ParserActionVerbs { return SHIFT }
 func (a *ShiftAction) NextState() int { return a.nextState }
 func (a *ShiftAction) ReduceByRule() int { return -1 }
 func (a *ShiftAction) Message() string { return fmt.Sprintf("shift symbol: %s", a.symbol) }
+func NewShiftAction(nextState int, symbol string) *ShiftAction { return &ShiftAction{nextState, symbol} }
 
 // ----- REDUCE ACTION ------
 type ReduceAction struct {
@@ -38,6 +39,7 @@ func (a *ReduceAction) ActionVerb() ParserActionVerbs { return REDUCE }
 func (a *ReduceAction) NextState() int { return -1 }
 func (a *ReduceAction) ReduceByRule() int { return a.ruleId }
 func (a *ReduceAction) Message() string { return fmt.Sprintf("reduce by rule id: %d", a.ruleId) }
+func NewReduceAction(ruleId int) *ReduceAction { return &ReduceAction{ruleId} }
 
 // ----- GOTO ACTION -----
 type GotoAction struct {
@@ -47,6 +49,7 @@ func (a *GotoAction) ActionVerb() ParserActionVerbs { return GOTO }
 func (a *GotoAction) NextState() int { return a.nextState }
 func (a *GotoAction) ReduceByRule() int { return -1 }
 func (a *GotoAction) Message() string { return fmt.Sprintf("go to state: %d", a.nextState) }
+func NewGotoAction(nextState int) *GotoAction { return &GotoAction{nextState} }
 
 // ----- ACCEPT ACTION -----
 type AcceptAction struct {}
@@ -63,3 +66,4 @@ func (a *ErrorAction) ActionVerb() ParserActionVerbs { return ERROR }
 func (a *ErrorAction) NextState() int { return -1 }
 func (a *ErrorAction) ReduceByRule() int { return -1 }
 func (a *ErrorAction) Message() string { return a.errorMessage }
+func NewErrorAction(errorMessage string) *ErrorAction { return &ErrorAction{errorMessage} }
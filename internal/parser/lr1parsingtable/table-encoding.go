@@ -0,0 +1,94 @@
+package lr1parsingtable
+
+import (
+	"fmt"
+	"interpreters/internal/parser/lr1grammar"
+	"strconv"
+)
+
+// Compact string form of an action used when serializing tables: `s4` (shift),
+// `r2` (reduce), `g4` (goto) and `acc` (accept).
+func EncodeAction(action ParserAction) string {
+	switch action.ActionVerb() {
+	case SHIFT:
+		return "s" + strconv.Itoa(action.NextState())
+	case REDUCE:
+		return "r" + strconv.Itoa(action.ReduceByRule())
+	case GOTO:
+		return "g" + strconv.Itoa(action.NextState())
+	case ACCEPT:
+		return "acc"
+	}
+	return ""
+}
+
+// Inverse of `EncodeAction`. `symbol` is the column of the action.
+func DecodeAction(encoded string, symbol string) (ParserAction, error) {
+	if (encoded == "acc") {
+		return &AcceptAction{}, nil
+	}
+	if (len(encoded) < 2) {
+		return nil, fmt.Errorf(`invalid encoded action: %q`, encoded)
+	}
+
+	number, err := strconv.Atoi(encoded[1:])
+	if err != nil {
+		return nil, fmt.Errorf(`invalid encoded action: %q`, encoded)
+	}
+	switch encoded[0] {
+	case 's':
+		return NewShiftAction(number, symbol), nil
+	case 'r':
+		return NewReduceAction(number), nil
+	case 'g':
+		return NewGotoAction(number), nil
+	}
+	return nil, fmt.Errorf(`invalid encoded action: %q`, encoded)
+}
+
+// Encodes every non-empty cell of the table, one map of symbol -> action per state.
+func (pt *ParsingTable) EncodeRows() []map[string]string {
+	rows := make([]map[string]string, pt.NumStates())
+	for state := range rows {
+		row := make(map[string]string)
+		for _, symbol := range append(pt.ActionSymbols(), pt.GotoSymbols()...) {
+			if action := pt.table.lookup(state, symbol); action != nil {
+				row[symbol] = EncodeAction(action)
+			}
+		}
+		rows[state] = row
+	}
+	return rows
+}
+
+/*
+Restores a `ParsingTable` from rows produced by `EncodeRows` without rebuilding the
+automaton. The restored table has no `Automaton`.
+*/
+func NewParsingTableFromRows(grammar *lr1grammar.Grammar, rows []map[string]string) (*ParsingTable, error) {
+	table := make([]map[string]ParserAction, len(rows))
+
+	for state, encodedRow := range rows {
+		row := make(map[string]ParserAction)
+		for symbol, encoded := range encodedRow {
+			if (!grammar.AllSymbols.Has(symbol)) {
+				return nil, fmt.Errorf(`state %d refers to unknown symbol: %s`, state, symbol)
+			}
+			action, err := DecodeAction(encoded, symbol)
+			if err != nil {
+				return nil, fmt.Errorf(`state %d: %w`, state, err)
+			}
+			isTransition := action.ActionVerb() == SHIFT || action.ActionVerb() == GOTO
+			if (isTransition && (action.NextState() < 0 || action.NextState() >= len(rows))) {
+				return nil, fmt.Errorf(`state %d refers to unknown state: %d`, state, action.NextState())
+			}
+			if _, exists := grammar.ProductionRules[uint(action.ReduceByRule())]; action.ActionVerb() == REDUCE && !exists {
+				return nil, fmt.Errorf(`state %d refers to unknown rule: %d`, state, action.ReduceByRule())
+			}
+			row[symbol] = action
+		}
+		table[state] = row
+	}
+
+	return &ParsingTable{grammar, nil, []Conflict{}, newCompressedTable(grammar, table), map[cell][]ParserAction{}}, nil
+}
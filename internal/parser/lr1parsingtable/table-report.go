@@ -0,0 +1,265 @@
+package lr1parsingtable
+
+import (
+	"fmt"
+	"html/template"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
+	"interpreters/utilities/arrays"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Terminal columns of the ACTION table: terminals in sorted order with EOF last.
+func (pt *ParsingTable) ActionSymbols() []string {
+	return actionSymbols(pt.Grammar)
+}
+
+// Non-terminal columns of the GOTO table in sorted order, without the augmented start.
+func (pt *ParsingTable) GotoSymbols() []string {
+	return gotoSymbols(pt.Grammar)
+}
+
+func actionSymbols(grammar *lr1grammar.Grammar) []string {
+	terminals := []string{}
+	for _, terminal := range grammar.Terminals.GetItems() {
+		if (terminal != symbols.Epsilon && terminal != symbols.EOF) {
+			terminals = append(terminals, terminal)
+		}
+	}
+	sort.Strings(terminals)
+	return append(terminals, symbols.EOF)
+}
+
+func gotoSymbols(grammar *lr1grammar.Grammar) []string {
+	nonTerminals := []string{}
+	for _, nonTerminal := range grammar.NonTerminals.GetItems() {
+		if (nonTerminal != symbols.AugmentedStart) {
+			nonTerminals = append(nonTerminals, nonTerminal)
+		}
+	}
+	sort.Strings(nonTerminals)
+	return nonTerminals
+}
+
+// Short form of an action as printed in table reports: the `EncodeAction` form,
+// except that gotos are printed as the bare state, e.g. `4` to go to state 4.
+func FormatAction(action ParserAction) string {
+	if (action.ActionVerb() == GOTO) {
+		return strconv.Itoa(action.NextState())
+	}
+	return EncodeAction(action)
+}
+
+// Text of every cell of the table, one row per state. Empty cells are "".
+func (pt *ParsingTable) reportRows() [][]string {
+	columns := append(pt.ActionSymbols(), pt.GotoSymbols()...)
+	rows := make([][]string, pt.NumStates())
+	for state := range rows {
+		row := []string{fmt.Sprintf("%d", state)}
+		for _, symbol := range columns {
+			row = append(row, FormatAction(pt.Action(state, symbol)))
+		}
+		rows[state] = row
+	}
+	return rows
+}
+
+func (pt *ParsingTable) formatRule(ruleId int) string {
+	rule := pt.Grammar.ProductionRules[uint(ruleId)]
+	return rule.NonTerminal + " -> " + strings.Join(rule.Production, " ")
+}
+
+// ----- TEXT -----
+
+// Renders the ACTION/GOTO table as an aligned text grid followed by the numbered
+// production rules.
+func (pt *ParsingTable) TextReport() string {
+	header := append([]string{"State"}, pt.ActionSymbols()...)
+	header = append(header, pt.GotoSymbols()...)
+	rows := append([][]string{header}, pt.reportRows()...)
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for idx, cell := range row {
+			widths[idx] = max(widths[idx], len([]rune(cell)))
+		}
+	}
+
+	// ACTION and GOTO columns are separated by a double bar
+	gotoStart := 1 + len(pt.ActionSymbols())
+	var builder strings.Builder
+	writeRow := func (row []string) {
+		var line strings.Builder
+		for idx, cell := range row {
+			if (idx == gotoStart) {
+				line.WriteString(" ||")
+			} else if (idx > 0) {
+				line.WriteString(" |")
+			}
+			line.WriteString(" " + cell + strings.Repeat(" ", widths[idx] - len([]rune(cell))))
+		}
+		builder.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+
+	writeRow(rows[0])
+	separator := make([]string, len(header))
+	for idx := range separator {
+		separator[idx] = strings.Repeat("-", widths[idx])
+	}
+	writeRow(separator)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	builder.WriteString("\nRules:\n")
+	for ruleId := 0; ruleId < len(pt.Grammar.ProductionRules); ruleId++ {
+		builder.WriteString(fmt.Sprintf("  r%d: %s\n", ruleId, pt.formatRule(ruleId)))
+	}
+	return builder.String()
+}
+
+// ----- MARKDOWN -----
+
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer(`|`, `\|`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`).Replace(text)
+}
+
+// Renders the ACTION/GOTO table as a Markdown table followed by the numbered
+// production rules.
+func (pt *ParsingTable) MarkdownReport() string {
+	header := append([]string{"State"}, pt.ActionSymbols()...)
+	header = append(header, pt.GotoSymbols()...)
+
+	var builder strings.Builder
+	builder.WriteString("| " + strings.Join(arrays.Map(header, func (symbol string) string {
+		return "`" + strings.ReplaceAll(symbol, "|", `\|`) + "`"
+	}), " | ") + " |\n")
+	builder.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range pt.reportRows() {
+		builder.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+
+	builder.WriteString("\n**Rules**\n\n")
+	for ruleId := 0; ruleId < len(pt.Grammar.ProductionRules); ruleId++ {
+		builder.WriteString(fmt.Sprintf("- r%d: %s\n", ruleId, escapeMarkdown(pt.formatRule(ruleId))))
+	}
+	return builder.String()
+}
+
+// ----- HTML -----
+
+type htmlReportCell struct {
+	Text string
+	Href string
+	IsGoto bool
+}
+
+type htmlReportTransition struct {
+	Symbol string
+	State int
+}
+
+type htmlReportState struct {
+	Id int
+	Cells []htmlReportCell
+	KernelItems []string
+	ClosureItems []string
+	Transitions []htmlReportTransition
+}
+
+type htmlReport struct {
+	ActionSymbols []string
+	GotoSymbols []string
+	States []htmlReportState
+	Rules []string
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>LR(1) parsing table</title>
+<style>
+  body { font-family: sans-serif; }
+  table { border-collapse: collapse; }
+  th, td { border: 1px solid #999; padding: 2px 8px; text-align: center; font-family: monospace; }
+  th.goto, td.goto { background: #f2f2f2; }
+  section:target { background: #fff3b3; }
+  pre { margin: 0; }
+</style>
+</head>
+<body>
+<h1>LR(1) parsing table</h1>
+<table>
+<tr><th>State</th>{{range .ActionSymbols}}<th>{{.}}</th>{{end}}{{range .GotoSymbols}}<th class="goto">{{.}}</th>{{end}}</tr>
+{{range .States}}<tr><th><a href="#state-{{.Id}}">{{.Id}}</a></th>{{range .Cells}}<td{{if .IsGoto}} class="goto"{{end}}>{{if .Href}}<a href="{{.Href}}">{{.Text}}</a>{{else}}{{.Text}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+<h2>Rules</h2>
+<ol start="0">
+{{range $id, $rule := .Rules}}<li id="rule-{{$id}}"><code>{{$rule}}</code></li>
+{{end}}</ol>
+<h2>States</h2>
+{{range .States}}<section id="state-{{.Id}}">
+<h3>State {{.Id}}</h3>
+<pre>{{range .KernelItems}}{{.}}
+{{end}}{{if .ClosureItems}}--
+{{range .ClosureItems}}{{.}}
+{{end}}{{end}}</pre>
+{{if .Transitions}}<p>Transitions: {{range .Transitions}}<a href="#state-{{.State}}"><code>{{.Symbol}}</code> &rarr; {{.State}}</a> {{end}}</p>{{end}}
+</section>
+{{end}}</body>
+</html>
+`))
+
+// Renders a standalone HTML page with the ACTION/GOTO table, the numbered rules and
+// the items of every state. Shift and goto cells link to their target state and
+// reduce cells to their rule. Items are omitted for tables without an `Automaton`.
+func (pt *ParsingTable) HTMLReport() (string, error) {
+	actionSymbols := pt.ActionSymbols()
+	gotoSymbols := pt.GotoSymbols()
+	report := htmlReport{actionSymbols, gotoSymbols, []htmlReportState{}, []string{}}
+
+	for ruleId := 0; ruleId < len(pt.Grammar.ProductionRules); ruleId++ {
+		report.Rules = append(report.Rules, pt.formatRule(ruleId))
+	}
+
+	for state := 0; state < pt.NumStates(); state++ {
+		reportState := htmlReportState{Id: state}
+		for idx, symbol := range append(append([]string{}, actionSymbols...), gotoSymbols...) {
+			action := pt.Action(state, symbol)
+			cell := htmlReportCell{Text: FormatAction(action), IsGoto: idx >= len(actionSymbols)}
+			switch action.ActionVerb() {
+			case SHIFT, GOTO:
+				cell.Href = fmt.Sprintf("#state-%d", action.NextState())
+			case REDUCE:
+				cell.Href = fmt.Sprintf("#rule-%d", action.ReduceByRule())
+			}
+			reportState.Cells = append(reportState.Cells, cell)
+		}
+
+		if (pt.Automaton != nil) {
+			closureSet := pt.Automaton.States[state].CLOSURESet
+			for _, item := range sortedItems(pt.Grammar, closureSet) {
+				if (closureSet.IsKernelItem(item)) {
+					reportState.KernelItems = append(reportState.KernelItems, pt.Automaton.FormatItem(item))
+				} else {
+					reportState.ClosureItems = append(reportState.ClosureItems, pt.Automaton.FormatItem(item))
+				}
+			}
+
+			nextStates := pt.Automaton.States[state].NextStates
+			for _, symbol := range sortedKeys(nextStates) {
+				reportState.Transitions = append(reportState.Transitions, htmlReportTransition{symbol, nextStates[symbol]})
+			}
+		}
+		report.States = append(report.States, reportState)
+	}
+
+	var builder strings.Builder
+	if err := htmlReportTemplate.Execute(&builder, report); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,50 @@
+/*
+Fixtures shared by the tests of the parser backends and grammar transformations.
+*/
+package parsertest
+
+import (
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"regexp"
+	"strings"
+)
+
+// A lexer config with one symbol token per type, matching the type literally.
+func SymbolTokens(types ...string) lexer.LexerConfigJson {
+	tokens := lexer.TokenConfigJsonArr{}
+	for _, typ := range types {
+		tokens = append(tokens, lexer.TokenConfigJson{Type: typ, Pattern: "(" + regexp.QuoteMeta(typ) + ")"})
+	}
+	return lexer.LexerConfigJson{SymbolTokens: tokens}
+}
+
+// Renders a tree as nested non-terminals around the token values.
+func Shape(element cst.Element) string {
+	switch element := element.(type) {
+	case *cst.Leaf:
+		return element.Token.Value
+	case *cst.Node:
+		parts := []string{element.NonTerminal}
+		for _, child := range element.Children {
+			parts = append(parts, Shape(child))
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	return ""
+}
+
+// Renders a tree as nested parentheses around the token values.
+func Bracketed(element cst.Element) string {
+	switch element := element.(type) {
+	case *cst.Leaf:
+		return element.Token.Value
+	case *cst.Node:
+		parts := []string{}
+		for _, child := range element.Children {
+			parts = append(parts, Bracketed(child))
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	return ""
+}
@@ -0,0 +1,393 @@
+package peg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/symbols"
+	"interpreters/utilities/files"
+	"slices"
+	"strings"
+)
+
+/*
+A PEG grammar: the token definitions and one rule per line in PEG syntax, e.g.
+
+	"rules": [
+		"VALUE <- OBJECT / ARRAY / str_lit / num_lit",
+		"OBJECT <- '{' (ENTRY (',' ENTRY)*)? '}'",
+		...
+	]
+
+Expressions are sequences separated by spaces, ordered choices `e1 / e2`,
+repetitions `e*`, `e+`, options `e?`, predicates `&e` and `!e` that match without
+consuming input, groups `(e)`, `.` for any token and `EPSILON` for the empty
+sequence. Names are rules if defined, token types otherwise, and quoted literal
+terminals get lexer tokens like in `lr1grammar.GrammarConfigJson`.
+*/
+type GrammarConfigJson struct {
+	Terminals lexer.LexerConfigJson `json:"terminals"`
+	Rules []string `json:"rules"`
+	// the first rule if empty
+	StartSymbol string `json:"startSymbol,omitempty"`
+	// names of terminals as shown in error messages, e.g. "str_lit": "string"
+	DisplayNames map[string]string `json:"displayNames,omitempty"`
+}
+
+// Reads and unmarshals a PEG `GrammarConfigJson` file.
+func ReadGrammarConfigJson(path string) (GrammarConfigJson, error) {
+	var data GrammarConfigJson
+
+	bytes, err := files.OpenFileToByteStream(path)
+	if err != nil {
+		return data, err
+	}
+
+	err = json.Unmarshal(bytes, &data)
+	if err != nil {
+		return data, errors.New(`Error unmarshalling config file: ` + err.Error())
+	}
+
+	return data, nil
+}
+
+type ExpressionKind int
+
+const (
+	Terminal ExpressionKind = iota
+	NonTerminal
+	AnyToken
+	Empty
+	Sequence
+	Choice
+	ZeroOrMore
+	OneOrMore
+	Optional
+	And
+	Not
+)
+
+type Expression struct {
+	Kind ExpressionKind
+	// token type of a `Terminal`, rule name of a `NonTerminal`
+	Symbol string
+	// items of a `Sequence` or `Choice`, the operand of the other operators
+	Items []*Expression
+}
+
+// Renders the expression in PEG syntax.
+func (e *Expression) String() string {
+	switch e.Kind {
+	case Terminal:
+		if (isName(e.Symbol)) {
+			return e.Symbol
+		}
+		return "'" + e.Symbol + "'"
+	case NonTerminal:
+		return e.Symbol
+	case AnyToken:
+		return "."
+	case Empty:
+		return symbols.Epsilon
+	case Sequence, Choice:
+		separator := " "
+		if (e.Kind == Choice) {
+			separator = " / "
+		}
+		items := make([]string, len(e.Items))
+		for idx, item := range e.Items {
+			items[idx] = item.String()
+			if (item.Kind == Choice || (e.Kind == Sequence && item.Kind == Sequence)) {
+				items[idx] = "(" + items[idx] + ")"
+			}
+		}
+		return strings.Join(items, separator)
+	}
+
+	operand := e.Items[0].String()
+	if (e.Items[0].Kind == Sequence || e.Items[0].Kind == Choice) {
+		operand = "(" + operand + ")"
+	}
+	switch e.Kind {
+	case ZeroOrMore:
+		return operand + "*"
+	case OneOrMore:
+		return operand + "+"
+	case Optional:
+		return operand + "?"
+	case And:
+		return "&" + operand
+	}
+	return "!" + operand
+}
+
+type Rule struct {
+	Name string
+	Expression *Expression
+	// rule id of the first alternative, see `Grammar`
+	FirstRuleId int
+}
+
+// The alternatives of the top-level choice of the rule, or its whole expression.
+func (r *Rule) Alternatives() []*Expression {
+	if (r.Expression.Kind == Choice) {
+		return r.Expression.Items
+	}
+	return []*Expression{r.Expression}
+}
+
+/*
+A validated PEG grammar. Each top-level alternative of a rule has a rule id, numbered
+like the productions of `lr1grammar.NewAugmentedGrammar`: 0 is the augmented start
+rule, then the rules sorted by name, their alternatives in order. A PEG grammar
+whose alternatives are plain sequences thus builds the same trees as the LR(1)
+grammar with the same productions.
+*/
+type Grammar struct {
+	// rules in definition order
+	Rules []*Rule
+	StartSymbol string
+	// token definitions, including the literal terminals of the rules
+	Tokens lexer.LexerConfigJson
+	// token types in definition order, then EOF
+	Terminals []string
+	DisplayNames map[string]string
+
+	rules map[string]*Rule
+}
+
+func NewGrammar(config GrammarConfigJson) (*Grammar, error) {
+	g := &Grammar{StartSymbol: config.StartSymbol, DisplayNames: config.DisplayNames, rules: make(map[string]*Rule)}
+	for _, text := range config.Rules {
+		rule, err := parseRule(text)
+		if (err != nil) {
+			return nil, err
+		}
+		if _, exists := g.rules[rule.Name]; exists {
+			return nil, fmt.Errorf(`Duplicate PEG rule: %s`, rule.Name)
+		}
+		g.Rules = append(g.Rules, rule)
+		g.rules[rule.Name] = rule
+	}
+	if (len(g.Rules) == 0) {
+		return nil, errors.New(`PEG grammar has no rules`)
+	}
+	if (g.StartSymbol == "") {
+		g.StartSymbol = g.Rules[0].Name
+	}
+	if _, exists := g.rules[g.StartSymbol]; !exists {
+		return nil, fmt.Errorf(`Undefined PEG start rule: %s`, g.StartSymbol)
+	}
+
+	err := g.resolveTerminals(config.Terminals)
+	if (err != nil) {
+		return nil, err
+	}
+	for _, rule := range g.Rules {
+		err := g.resolveNames(rule, rule.Expression)
+		if (err != nil) {
+			return nil, err
+		}
+	}
+	err = g.validate()
+	if (err != nil) {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(g.Rules))
+	for _, rule := range g.Rules {
+		names = append(names, rule.Name)
+	}
+	lr1grammar.SortNonTerminals(names)
+	ruleId := 1
+	for _, name := range names {
+		g.rules[name].FirstRuleId = ruleId
+		ruleId += len(g.rules[name].Alternatives())
+	}
+	return g, nil
+}
+
+func NewGrammarFromJsonConfig(path string) (*Grammar, error) {
+	config, err := ReadGrammarConfigJson(path)
+	if (err != nil) {
+		return nil, err
+	}
+	return NewGrammar(config)
+}
+
+// Get a rule by name, `nil` if it is not defined.
+func (g *Grammar) Rule(name string) *Rule {
+	return g.rules[name]
+}
+
+// Get the name of a terminal as shown in error messages, like
+// `lr1grammar.Grammar.DisplayName`.
+func (g *Grammar) DisplayName(terminal string) string {
+	return lr1grammar.TerminalDisplayName(g.DisplayNames, terminal)
+}
+
+// Registers lexer tokens for the literal terminals the way `lr1grammar` does, and
+// turns them into plain terminals.
+func (g *Grammar) resolveTerminals(tokens lexer.LexerConfigJson) error {
+	literals := make(map[string][]lr1grammar.ProductionJson)
+	for _, rule := range g.Rules {
+		production := lr1grammar.ProductionJson{}
+		walk(rule.Expression, func (e *Expression) {
+			if (e.Kind == Terminal) {
+				production.Symbols = append(production.Symbols, e.Symbol)
+				e.Symbol = e.Symbol[1:len(e.Symbol) - 1]
+			}
+		})
+		literals[rule.Name] = []lr1grammar.ProductionJson{production}
+	}
+	resolved, err := lr1grammar.ResolveLiteralTerminals(lr1grammar.GrammarConfigJson{Terminals: tokens, NonTerminals: literals})
+	if (err != nil) {
+		return err
+	}
+	g.Tokens = resolved.Terminals
+
+	for _, tokenGroup := range []lexer.TokenConfigJsonArr{g.Tokens.SymbolTokens, g.Tokens.KeywordTokens, g.Tokens.GenericTokens} {
+		for _, token := range tokenGroup {
+			g.Terminals = append(g.Terminals, token.Type)
+		}
+	}
+	g.Terminals = append(g.Terminals, symbols.EOF)
+	return nil
+}
+
+// Names are parsed as non-terminals: those that are not rules must be token types.
+func (g *Grammar) resolveNames(rule *Rule, expression *Expression) error {
+	var err error
+	walk(expression, func (e *Expression) {
+		if (e.Kind != NonTerminal || g.rules[e.Symbol] != nil || err != nil) {
+			return
+		}
+		if (!slices.Contains(g.Terminals, e.Symbol) || e.Symbol == symbols.EOF) {
+			err = fmt.Errorf(`Undefined symbol %s in PEG rule %s`, e.Symbol, rule.Name)
+			return
+		}
+		e.Kind = Terminal
+	})
+	return err
+}
+
+func walk(e *Expression, visit func (e *Expression)) {
+	visit(e)
+	for _, item := range e.Items {
+		walk(item, visit)
+	}
+}
+
+/*
+Rejects the grammars the packrat parser could loop on: rules that call themselves
+without consuming input (left recursion) and repetitions of expressions that match
+empty input.
+*/
+func (g *Grammar) validate() error {
+	nullable := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.Rules {
+			if (!nullable[rule.Name] && matchesEmpty(rule.Expression, nullable)) {
+				nullable[rule.Name] = true
+				changed = true
+			}
+		}
+	}
+
+	for _, rule := range g.Rules {
+		var err error
+		walk(rule.Expression, func (e *Expression) {
+			if ((e.Kind == ZeroOrMore || e.Kind == OneOrMore) && matchesEmpty(e.Items[0], nullable) && err == nil) {
+				err = fmt.Errorf(`Repetition of an expression matching empty input in PEG rule %s: %s`, rule.Name, e)
+			}
+		})
+		if (err != nil) {
+			return err
+		}
+	}
+
+	// depth-first search for a cycle of calls at the same input position
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	path := []string{}
+	var visit func (name string) error
+	visit = func (name string) error {
+		switch state[name] {
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return fmt.Errorf(`Left recursion in PEG rules: %s`, strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, callee := range leftCalls(g.rules[name].Expression, nullable) {
+			err := visit(callee)
+			if (err != nil) {
+				return err
+			}
+		}
+		path = path[:len(path) - 1]
+		state[name] = visited
+		return nil
+	}
+	for _, rule := range g.Rules {
+		err := visit(rule.Name)
+		if (err != nil) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Whether an expression can succeed without consuming input, given the nullable rules.
+func matchesEmpty(e *Expression, nullable map[string]bool) bool {
+	switch e.Kind {
+	case Terminal, AnyToken:
+		return false
+	case NonTerminal:
+		return nullable[e.Symbol]
+	case Sequence:
+		for _, item := range e.Items {
+			if (!matchesEmpty(item, nullable)) {
+				return false
+			}
+		}
+		return true
+	case Choice:
+		return slices.ContainsFunc(e.Items, func (item *Expression) bool { return matchesEmpty(item, nullable) })
+	case OneOrMore:
+		return matchesEmpty(e.Items[0], nullable)
+	}
+	// Empty, ZeroOrMore, Optional and the predicates
+	return true
+}
+
+// Rules an expression can call before consuming any input.
+func leftCalls(e *Expression, nullable map[string]bool) []string {
+	switch e.Kind {
+	case NonTerminal:
+		return []string{e.Symbol}
+	case Sequence:
+		calls := []string{}
+		for _, item := range e.Items {
+			calls = append(calls, leftCalls(item, nullable)...)
+			if (!matchesEmpty(item, nullable)) {
+				break
+			}
+		}
+		return calls
+	}
+	calls := []string{}
+	for _, item := range e.Items {
+		calls = append(calls, leftCalls(item, nullable)...)
+	}
+	return calls
+}
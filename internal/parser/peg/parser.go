@@ -0,0 +1,221 @@
+package peg
+
+import (
+	"fmt"
+	"interpreters/internal/cst"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/symbols"
+	"io"
+	"slices"
+)
+
+/*
+A packrat parser for a PEG grammar: ordered choices commit to their first matching
+alternative and every rule is matched at most once per token position, which makes
+parsing linear in the input. Builds the same trees as the LR(1) driver: a `cst.Node`
+per matched rule, whose children are the tokens and nodes matched by its alternative
+in order; predicates contribute nothing.
+*/
+type Parser struct {
+	Lexer *lexer.Lexer
+	Grammar *Grammar
+}
+
+func NewParser(config GrammarConfigJson) (*Parser, error) {
+	grammar, err := NewGrammar(config)
+	if err != nil {
+		return nil, err
+	}
+
+	lex, err := lexer.CreateLexer(grammar.Tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{lex, grammar}, nil
+}
+
+func NewParserFromJsonConfig(path string) (*Parser, error) {
+	config, err := ReadGrammarConfigJson(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewParser(config)
+}
+
+// Parses an input into a concrete syntax tree rooted at the start rule.
+func (p *Parser) ParseString(input string) (*cst.Node, error) {
+	tokens, err := p.Lexer.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ParseTokens(*tokens)
+}
+
+func (p *Parser) ParseReader(reader io.Reader) (*cst.Node, error) {
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf(`Error reading input: %w`, err)
+	}
+
+	return p.ParseString(string(bytes))
+}
+
+/*
+Parses a token stream terminated by an EOF token. The start rule must match the whole
+input. Returns an `*lr1parser.SyntaxError` at the farthest token any terminal failed
+to match, listing the terminals tried there; failures inside predicates do not count.
+*/
+func (p *Parser) ParseTokens(tokens []*lexer.Token) (*cst.Node, error) {
+	pr := &packrat{grammar: p.Grammar, tokens: tokens, memo: make(map[memoKey]memoEntry)}
+	whole := &Expression{Kind: Sequence, Items: []*Expression{
+		{Kind: NonTerminal, Symbol: p.Grammar.StartSymbol},
+		{Kind: Terminal, Symbol: symbols.EOF},
+	}}
+	elements, _, ok := pr.match(whole, 0)
+	if (ok) {
+		return elements[0].(*cst.Node), nil
+	}
+
+	expected := slices.Clone(pr.expected)
+	slices.SortFunc(expected, func (a string, b string) int {
+		return slices.Index(p.Grammar.Terminals, a) - slices.Index(p.Grammar.Terminals, b)
+	})
+	return nil, lr1parser.NewSyntaxError(p.Grammar.DisplayName, tokens[pr.farthest], expected)
+}
+
+type memoKey struct {
+	rule string
+	pos int
+	inPredicate bool
+}
+
+type memoEntry struct {
+	node *cst.Node
+	end int
+	ok bool
+}
+
+type packrat struct {
+	grammar *Grammar
+	tokens []*lexer.Token
+	memo map[memoKey]memoEntry
+	// farthest failed terminal and the terminals that failed there
+	farthest int
+	expected []string
+	predicates int
+}
+
+func (pr *packrat) fail(pos int, terminal string) {
+	if (pr.predicates > 0 || pos < pr.farthest) {
+		return
+	}
+	if (pos > pr.farthest) {
+		pr.farthest = pos
+		pr.expected = nil
+	}
+	if (!slices.Contains(pr.expected, terminal)) {
+		pr.expected = append(pr.expected, terminal)
+	}
+}
+
+// Matches an expression at token `pos`. Returns the elements it contributes to the
+// node of the rule and the position after it.
+func (pr *packrat) match(e *Expression, pos int) ([]cst.Element, int, bool) {
+	switch e.Kind {
+	case Terminal:
+		if (pos < len(pr.tokens) && pr.tokens[pos].Type == e.Symbol) {
+			return []cst.Element{cst.NewLeaf(pr.tokens[pos])}, pos + 1, true
+		}
+		pr.fail(pos, e.Symbol)
+		return nil, pos, false
+
+	case AnyToken:
+		if (pos < len(pr.tokens) && pr.tokens[pos].Type != symbols.EOF) {
+			return []cst.Element{cst.NewLeaf(pr.tokens[pos])}, pos + 1, true
+		}
+		return nil, pos, false
+
+	case NonTerminal:
+		node, end, ok := pr.matchRule(pr.grammar.rules[e.Symbol], pos)
+		if (!ok) {
+			return nil, pos, false
+		}
+		return []cst.Element{node}, end, true
+
+	case Empty:
+		return nil, pos, true
+
+	case Sequence:
+		elements := []cst.Element{}
+		end := pos
+		for _, item := range e.Items {
+			contributed, next, ok := pr.match(item, end)
+			if (!ok) {
+				return nil, pos, false
+			}
+			elements = append(elements, contributed...)
+			end = next
+		}
+		return elements, end, true
+
+	case Choice:
+		for _, item := range e.Items {
+			elements, end, ok := pr.match(item, pos)
+			if (ok) {
+				return elements, end, true
+			}
+		}
+		return nil, pos, false
+
+	case ZeroOrMore, OneOrMore, Optional:
+		elements := []cst.Element{}
+		end := pos
+		for count := 0; e.Kind != Optional || count < 1; count++ {
+			contributed, next, ok := pr.match(e.Items[0], end)
+			if (!ok) {
+				if (count == 0 && e.Kind == OneOrMore) {
+					return nil, pos, false
+				}
+				break
+			}
+			elements = append(elements, contributed...)
+			end = next
+		}
+		return elements, end, true
+	}
+
+	// predicates
+	pr.predicates++
+	_, _, ok := pr.match(e.Items[0], pos)
+	pr.predicates--
+	return nil, pos, ok == (e.Kind == And)
+}
+
+// Matches a rule once per position, trying its alternatives in order.
+func (pr *packrat) matchRule(rule *Rule, pos int) (*cst.Node, int, bool) {
+	key := memoKey{rule.Name, pos, pr.predicates > 0}
+	if entry, exists := pr.memo[key]; exists {
+		return entry.node, entry.end, entry.ok
+	}
+
+	entry := memoEntry{nil, pos, false}
+	for idx, alternative := range rule.Alternatives() {
+		elements, end, ok := pr.match(alternative, pos)
+		if (ok) {
+			if (elements == nil) {
+				elements = []cst.Element{}
+			}
+			node := cst.NewNode(rule.Name, rule.FirstRuleId + idx, elements)
+			// like `lr1parser.TreeBuilder`: nothing is dropped, so the children give the span
+			node.SourceSpan = node.Span()
+			entry = memoEntry{node, end, true}
+			break
+		}
+	}
+	pr.memo[key] = entry
+	return entry.node, entry.end, entry.ok
+}
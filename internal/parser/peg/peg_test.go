@@ -0,0 +1,175 @@
+package peg_test
+
+import (
+	"errors"
+	"interpreters/internal/lexer"
+	"interpreters/internal/parser/lr1grammar"
+	"interpreters/internal/parser/lr1parser"
+	"interpreters/internal/parser/parsertest"
+	"interpreters/internal/parser/peg"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func lexerConfig() lexer.LexerConfigJson {
+	return lexer.LexerConfigJson{
+		GenericTokens: lexer.TokenConfigJsonArr{
+			{Type: "id", Pattern: `([a-z]\w*)`},
+			{Type: "num", Pattern: `(\d+)`},
+		},
+	}
+}
+
+func TestParserMatchesLR1Trees(t *testing.T) {
+	lr1Config, err := lr1grammar.ReadGrammarConfigJson("../lr1parser/grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	// the JSON grammar of the LR(1) tests with names PEG can refer to
+	lr1Config.NonTerminals = map[string][]lr1grammar.ProductionJson{
+		"VALUE": lr1grammar.NewProductionsJson([]string{"OBJECT"}, []string{"ARRAY"}, []string{"true"}, []string{"false"}, []string{"null"}, []string{"str_lit"}, []string{"num_lit"}),
+		"OBJECT": lr1grammar.NewProductionsJson([]string{"{", "ENTRIES", "}"}),
+		"ENTRIES": lr1grammar.NewProductionsJson([]string{"ENTRY", "MORE_ENTRIES"}, []string{"EPSILON"}),
+		"MORE_ENTRIES": lr1grammar.NewProductionsJson([]string{",", "ENTRY", "MORE_ENTRIES"}, []string{"EPSILON"}),
+		"ENTRY": lr1grammar.NewProductionsJson([]string{"KEY", ":", "VALUE"}),
+		"KEY": lr1grammar.NewProductionsJson([]string{"str_lit"}, []string{"num_lit"}),
+		"ARRAY": lr1grammar.NewProductionsJson([]string{"[", "ELEMENTS", "]"}),
+		"ELEMENTS": lr1grammar.NewProductionsJson([]string{"VALUE", "MORE_ELEMENTS"}, []string{"EPSILON"}),
+		"MORE_ELEMENTS": lr1grammar.NewProductionsJson([]string{",", "VALUE", "MORE_ELEMENTS"}, []string{"EPSILON"}),
+	}
+	lr1, err := lr1parser.NewParser(lr1Config)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	parser, err := peg.NewParser(peg.GrammarConfigJson{
+		Terminals: lr1Config.Terminals,
+		Rules: []string{
+			"VALUE <- OBJECT / ARRAY / true / false / null / str_lit / num_lit",
+			"OBJECT <- '{' ENTRIES '}'",
+			"ENTRIES <- ENTRY MORE_ENTRIES / EPSILON",
+			"MORE_ENTRIES <- ',' ENTRY MORE_ENTRIES / EPSILON",
+			"ENTRY <- KEY ':' VALUE",
+			"KEY <- str_lit / num_lit",
+			"ARRAY <- '[' ELEMENTS ']'",
+			"ELEMENTS <- VALUE MORE_ELEMENTS / EPSILON",
+			"MORE_ELEMENTS <- ',' VALUE MORE_ELEMENTS / EPSILON",
+		},
+	})
+	if (err != nil) {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{
+		`{ "a": [1, 2, { "b": null }], "c": true }`,
+		`[]`,
+		`"text"`,
+	} {
+		expected, err := lr1.ParseString(input)
+		if (err != nil) {
+			t.Fatal(err)
+		}
+		tree, err := parser.ParseString(input)
+		if (err != nil) {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if diff := deep.Equal(tree, expected); diff != nil {
+			t.Errorf("%q: %v", input, diff)
+		}
+	}
+}
+
+func TestParserFlattensRepetitions(t *testing.T) {
+	parser, err := peg.NewParserFromJsonConfig("grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	tree, err := parser.ParseString(`{ "a": [1, 2], "b": {} }`)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := `(VALUE (OBJECT { (ENTRY (KEY "a") : (VALUE (ARRAY [ (VALUE 1) , (VALUE 2) ]))) , (ENTRY (KEY "b") : (VALUE (OBJECT { }))) }))`
+	if (parsertest.Shape(tree) != expected) {
+		t.Errorf("expected %s, got %s", expected, parsertest.Shape(tree))
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	parser, err := peg.NewParser(peg.GrammarConfigJson{
+		Rules: []string{
+			"STATEMENTS <- STATEMENT*",
+			// an identifier followed by '=' starts an assignment, any other one an expression
+			"STATEMENT <- &(id '=') ASSIGNMENT / EXPRESSION ';' / COMMENT",
+			"ASSIGNMENT <- id '=' EXPRESSION ';'",
+			"EXPRESSION <- id / num",
+			"COMMENT <- '#' (!'#' .)* '#'",
+		},
+		Terminals: lexerConfig(),
+	})
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	tree, err := parser.ParseString(`x = 1; x; # y = ; # 2;`)
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	expected := `(STATEMENTS (STATEMENT (ASSIGNMENT x = (EXPRESSION 1) ;)) (STATEMENT (EXPRESSION x) ;) (STATEMENT (COMMENT # y = ; #)) (STATEMENT (EXPRESSION 2) ;))`
+	if (parsertest.Shape(tree) != expected) {
+		t.Errorf("expected %s, got %s", expected, parsertest.Shape(tree))
+	}
+}
+
+func TestOrderedChoiceCommits(t *testing.T) {
+	parser, err := peg.NewParser(peg.GrammarConfigJson{
+		Rules: []string{"S <- id / id num"},
+		Terminals: lexerConfig(),
+	})
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	_, err = parser.ParseString(`x 1`)
+	var syntaxError *lr1parser.SyntaxError
+	if (!errors.As(err, &syntaxError)) {
+		t.Fatalf("expected a syntax error, got %v", err)
+	}
+	if (err.Error() != "unexpected '1' at 1:3, expected end of input") {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	parser, err := peg.NewParserFromJsonConfig("grammar-config.json")
+	if (err != nil) {
+		t.Fatal(err)
+	}
+	for input, expected := range map[string]string{
+		`{ "a" 1 }`: "unexpected '1' at 1:7, expected ':'",
+		`[1, ]`: "unexpected ']' at 1:5, expected '[', '{', true, false, null, string or number",
+		`{ "a": 1`: "unexpected end of input at 1:9, expected ',' or '}'",
+		`[1] 2`: "unexpected '2' at 1:5, expected end of input",
+	} {
+		_, err := parser.ParseString(input)
+		if (err == nil || err.Error() != expected) {
+			t.Errorf("%q: expected %q, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestInvalidGrammars(t *testing.T) {
+	for rules, expected := range map[string]string{
+		"E <- E '+' num / num": "Left recursion in PEG rules: E -> E",
+		"A <- B? A 'a' / 'b'|B <- 'c'": "Left recursion in PEG rules: A -> A",
+		"A <- ('a'?)*": "Repetition of an expression matching empty input in PEG rule A: a?*",
+		"A <- 'a' B": "Undefined symbol B in PEG rule A",
+		"A <- ('a'": `Invalid PEG rule "A <- ('a'": missing ')'`,
+		"A <- 'a' / ": `Invalid PEG rule "A <- 'a' / ": empty alternative, write EPSILON instead`,
+		"A <- 'a'|A <- 'b'": "Duplicate PEG rule: A",
+		"A <- 'A' B|B <- 'b'": "Literal terminal 'A' clashes with the non-terminal: A",
+	} {
+		_, err := peg.NewParser(peg.GrammarConfigJson{Rules: strings.Split(rules, "|"), Terminals: lexerConfig()})
+		if (err == nil || err.Error() != expected) {
+			t.Errorf("%s: expected %q, got %v", rules, expected, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package peg
+
+import (
+	"fmt"
+	"interpreters/internal/symbols"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var namePattern = regexp.MustCompile(`^\w+$`)
+
+func isName(text string) bool {
+	return namePattern.MatchString(text)
+}
+
+// Reads a rule `Name <- expression`. Quoted literals are kept with their quotes as
+// `Terminal` symbols and names are `NonTerminal` symbols until the grammar resolves them.
+func parseRule(text string) (*Rule, error) {
+	name, expression, found := strings.Cut(text, "<-")
+	name = strings.TrimSpace(name)
+	if (!found || !isName(name)) {
+		return nil, fmt.Errorf(`Invalid PEG rule %q: expected "Name <- expression"`, text)
+	}
+
+	tokens, err := scanExpression(expression)
+	if (err != nil) {
+		return nil, fmt.Errorf(`Invalid PEG rule %q: %w`, text, err)
+	}
+	p := &expressionParser{tokens: tokens}
+	e, err := p.choice()
+	if (err == nil && p.pos < len(p.tokens)) {
+		err = fmt.Errorf(`unexpected %s`, p.tokens[p.pos])
+	}
+	if (err != nil) {
+		return nil, fmt.Errorf(`Invalid PEG rule %q: %w`, text, err)
+	}
+	return &Rule{Name: name, Expression: e}, nil
+}
+
+// Splits an expression into names, quoted literals and operators.
+func scanExpression(text string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(text)
+	for idx := 0; idx < len(runes); {
+		r := runes[idx]
+		switch {
+		case unicode.IsSpace(r):
+			idx++
+		case strings.ContainsRune("/&!*+?().", r):
+			tokens = append(tokens, string(r))
+			idx++
+		case r == '\'' || r == '"':
+			end := idx + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if (end == len(runes) || end == idx + 1) {
+				return nil, fmt.Errorf(`invalid literal %s`, string(runes[idx:end]))
+			}
+			tokens = append(tokens, string(runes[idx:end + 1]))
+			idx = end + 1
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			end := idx
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			tokens = append(tokens, string(runes[idx:end]))
+			idx = end
+		default:
+			return nil, fmt.Errorf(`unexpected character %q`, r)
+		}
+	}
+	return tokens, nil
+}
+
+/*
+Recursive descent over the scanned expression, from the lowest precedence:
+
+	choice   <- sequence ('/' sequence)*
+	sequence <- prefixed+
+	prefixed <- ('&' / '!')? suffixed
+	suffixed <- primary ('*' / '+' / '?')*
+	primary  <- name / literal / '.' / '(' choice ')'
+*/
+type expressionParser struct {
+	tokens []string
+	pos int
+}
+
+func (p *expressionParser) peek() string {
+	if (p.pos < len(p.tokens)) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *expressionParser) choice() (*Expression, error) {
+	items := []*Expression{}
+	for {
+		item, err := p.sequence()
+		if (err != nil) {
+			return nil, err
+		}
+		items = append(items, item)
+		if (p.peek() != "/") {
+			break
+		}
+		p.pos++
+	}
+	if (len(items) == 1) {
+		return items[0], nil
+	}
+	return &Expression{Kind: Choice, Items: items}, nil
+}
+
+func (p *expressionParser) sequence() (*Expression, error) {
+	items := []*Expression{}
+	for p.peek() != "" && p.peek() != "/" && p.peek() != ")" {
+		item, err := p.prefixed()
+		if (err != nil) {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	switch len(items) {
+	case 0:
+		return nil, fmt.Errorf(`empty alternative, write %s instead`, symbols.Epsilon)
+	case 1:
+		return items[0], nil
+	}
+	return &Expression{Kind: Sequence, Items: items}, nil
+}
+
+func (p *expressionParser) prefixed() (*Expression, error) {
+	kinds := map[string]ExpressionKind{"&": And, "!": Not}
+	kind, isPredicate := kinds[p.peek()]
+	if (!isPredicate) {
+		return p.suffixed()
+	}
+	p.pos++
+	operand, err := p.suffixed()
+	if (err != nil) {
+		return nil, err
+	}
+	return &Expression{Kind: kind, Items: []*Expression{operand}}, nil
+}
+
+func (p *expressionParser) suffixed() (*Expression, error) {
+	e, err := p.primary()
+	if (err != nil) {
+		return nil, err
+	}
+	kinds := map[string]ExpressionKind{"*": ZeroOrMore, "+": OneOrMore, "?": Optional}
+	for {
+		kind, isSuffix := kinds[p.peek()]
+		if (!isSuffix) {
+			return e, nil
+		}
+		p.pos++
+		e = &Expression{Kind: kind, Items: []*Expression{e}}
+	}
+}
+
+func (p *expressionParser) primary() (*Expression, error) {
+	token := p.peek()
+	p.pos++
+	switch {
+	case token == "(":
+		e, err := p.choice()
+		if (err != nil) {
+			return nil, err
+		}
+		if (p.peek() != ")") {
+			return nil, fmt.Errorf(`missing ')'`)
+		}
+		p.pos++
+		return e, nil
+	case token == ".":
+		return &Expression{Kind: AnyToken}, nil
+	case token == symbols.Epsilon:
+		return &Expression{Kind: Empty}, nil
+	case isName(token):
+		return &Expression{Kind: NonTerminal, Symbol: token}, nil
+	case strings.HasPrefix(token, "'") || strings.HasPrefix(token, `"`):
+		return &Expression{Kind: Terminal, Symbol: token}, nil
+	case token == "":
+		return nil, fmt.Errorf(`unexpected end of expression`)
+	}
+	return nil, fmt.Errorf(`unexpected %s`, token)
+}
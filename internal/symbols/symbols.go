@@ -5,4 +5,6 @@ const (
 	Epsilon = "EPSILON"
 	Dot = "•"
 	AugmentedStart = "G'"
+	// terminal standing for erroneous input in error productions
+	Error = "error"
 )
\ No newline at end of file
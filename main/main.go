@@ -1,23 +1,67 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"interpreters/internal/parser/lr1grammar"
-	"interpreters/internal/parser/lr1parsingtable"
-
-	"github.com/davecgh/go-spew/spew"
+	"interpreters/internal/cst"
+	"interpreters/internal/parser/lr1codegen"
+	"interpreters/internal/parser/lr1parser"
 )
 
 func main() {
-	Grammar, err := lr1grammar.NewAugmentedGrammarFromJsonConfig("./grammar-config.json")
+	format := flag.String("format", "sexpr", "parse tree output format: sexpr, json or dot")
+	report := flag.String("report", "text", "parsing table report format: text, markdown, html or none")
+	generate := flag.String("generate", "", "print a standalone Go parser for the grammar in the given package and exit")
+	flag.Parse()
+
+	if (*generate != "") {
+		source, err := lr1codegen.GenerateFromJsonConfig("./grammar-config.json", lr1codegen.Options{PackageName: *generate})
+		if (err != nil) {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Print(string(source))
+		return
+	}
+
+	Parser, err := lr1parser.NewParserFromJsonConfig("./grammar-config.json")
 	if (err != nil) {
 		fmt.Println(err.Error())
+		return
+	}
+
+	switch *report {
+	case "none":
+	case "markdown":
+		fmt.Println(Parser.Table.MarkdownReport())
+	case "html":
+		html, err := Parser.Table.HTMLReport()
+		if (err != nil) {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(html)
+	default:
+		fmt.Println(Parser.Table.TextReport())
 	}
-	
-	automaton, err := lr1parsingtable.NewLR1Automaton(Grammar)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println(spew.Sdump(automaton))
+
+	tree, err := Parser.ParseString(`{ "prop_a": [1, 2, { "prop_b": null }], "prop_c": true }`)
+	if (err != nil) {
+		fmt.Println(err.Error())
+		return
+	}
+
+	switch *format {
+	case "json":
+		bytes, err := cst.ToJSON(tree)
+		if (err != nil) {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(string(bytes))
+	case "dot":
+		fmt.Print(cst.ToDOT(tree))
+	default:
+		fmt.Println(cst.ToSExpr(tree))
 	}
-}
\ No newline at end of file
+}
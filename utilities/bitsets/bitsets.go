@@ -0,0 +1,94 @@
+package bitsets
+
+import "math/bits"
+
+// Set of small non-negative integers, one bit per integer.
+type Bitset []uint64
+
+// Builds an empty `Bitset` able to hold the integers 0 to `size - 1`.
+func New(size int) Bitset {
+	return make(Bitset, (size + 63) / 64)
+}
+
+// Builds a `Bitset` of the given `size` holding `items`.
+func Of(size int, items ...int) Bitset {
+	b := New(size)
+	for _, item := range items {
+		b.Add(item)
+	}
+	return b
+}
+
+// ----- BITSET METHODS -----
+
+func (b Bitset) Clone() Bitset {
+	return append(Bitset(nil), b...)
+}
+
+func (b Bitset) Add(item int) {
+	b[item / 64] |= 1 << (item % 64)
+}
+
+func (b Bitset) Delete(item int) {
+	b[item / 64] &^= 1 << (item % 64)
+}
+
+func (b Bitset) Has(item int) bool {
+	return item >= 0 && item / 64 < len(b) && b[item / 64] & (1 << (item % 64)) != 0
+}
+
+func (b Bitset) Size() int {
+	size := 0
+	for _, word := range b {
+		size += bits.OnesCount64(word)
+	}
+	return size
+}
+
+func (b Bitset) IsEmpty() bool {
+	for _, word := range b {
+		if (word != 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds every item of `other` to this `Bitset`, which must be at least as large.
+// Returns whether this `Bitset` changed.
+func (b Bitset) UnionWith(other Bitset) bool {
+	changed := false
+	for idx, word := range other {
+		if (b[idx] | word != b[idx]) {
+			b[idx] |= word
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Whether every item of `other` is in this `Bitset`.
+func (b Bitset) Contains(other Bitset) bool {
+	for idx, word := range other {
+		if (word != 0 && (idx >= len(b) || b[idx] & word != word)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b Bitset) IsEqual(other Bitset) bool {
+	return b.Contains(other) && other.Contains(b)
+}
+
+// Get the items in increasing order.
+func (b Bitset) Items() []int {
+	items := make([]int, 0, b.Size())
+	for idx, word := range b {
+		for word != 0 {
+			items = append(items, idx * 64 + bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return items
+}